@@ -0,0 +1,118 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"sort"
+	"strconv"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// AnchorReport describes the YAML anchors and aliases used in a values file.
+type AnchorReport struct {
+	// Anchors lists the names of the anchors (&name) that are defined, sorted.
+	Anchors []string
+	// Aliases maps the dot-separated path of each alias (*name) to the name
+	// of the anchor it refers to. An alias used as a merge key (<<: *name)
+	// is reported at the path of the map it is merged into.
+	Aliases map[string]string
+	// Resolved holds the values with every alias replaced by the value of
+	// its anchor. It is only set when requested from ReportAnchors.
+	Resolved Values
+}
+
+// UsesAnchors reports whether any anchors or aliases were found.
+func (r *AnchorReport) UsesAnchors() bool {
+	return len(r.Anchors) > 0 || len(r.Aliases) > 0
+}
+
+// ReportAnchors finds the YAML anchors and aliases in a chart's values.
+//
+// Aliases are expanded when values are read, so a value that appears to be
+// set twice may in fact come from an anchor elsewhere in the file. This helps
+// to explain where such values come from. If resolve is true, the values are
+// also parsed with all aliases expanded, as templates see them, and stored
+// in the report's Resolved field.
+func ReportAnchors(vals *chart.Config, resolve bool) (*AnchorReport, error) {
+	r := &AnchorReport{Aliases: map[string]string{}}
+	if vals == nil || vals.Raw == "" {
+		if resolve {
+			r.Resolved = Values{}
+		}
+		return r, nil
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(vals.Raw), &doc); err != nil {
+		return nil, err
+	}
+	anchors := map[string]bool{}
+	walkAnchors(&doc, "", anchors, r.Aliases)
+	for a := range anchors {
+		r.Anchors = append(r.Anchors, a)
+	}
+	sort.Strings(r.Anchors)
+
+	if resolve {
+		v, err := ReadValues([]byte(vals.Raw))
+		if err != nil {
+			return nil, err
+		}
+		r.Resolved = v
+	}
+	return r, nil
+}
+
+// walkAnchors records the anchors and aliases in n and its children, where n
+// is found at path.
+func walkAnchors(n *yamlv3.Node, path string, anchors map[string]bool, aliases map[string]string) {
+	if n.Anchor != "" {
+		anchors[n.Anchor] = true
+	}
+	switch n.Kind {
+	case yamlv3.AliasNode:
+		aliases[path] = n.Value
+	case yamlv3.DocumentNode:
+		for _, c := range n.Content {
+			walkAnchors(c, path, anchors, aliases)
+		}
+	case yamlv3.SequenceNode:
+		for i, c := range n.Content {
+			walkAnchors(c, joinPath(path, strconv.Itoa(i)), anchors, aliases)
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			p := joinPath(path, key.Value)
+			if key.Value == "<<" {
+				p = path
+			}
+			walkAnchors(val, p, anchors, aliases)
+		}
+	}
+}
+
+// joinPath appends name to a dot-separated path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
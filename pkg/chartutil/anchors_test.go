@@ -0,0 +1,82 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const anchoredValues = `defaults: &defaults
+  replicas: 2
+  image: nginx
+staging:
+  <<: *defaults
+  replicas: 1
+production:
+  settings: *defaults
+hosts:
+  - &primary example.com
+  - *primary
+`
+
+func TestReportAnchors(t *testing.T) {
+	r, err := ReportAnchors(&chart.Config{Raw: anchoredValues}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.UsesAnchors() {
+		t.Error("Expected anchors to be reported")
+	}
+	if expect := []string{"defaults", "primary"}; !reflect.DeepEqual(r.Anchors, expect) {
+		t.Errorf("Expected anchors %v, got %v", expect, r.Anchors)
+	}
+	expect := map[string]string{
+		"staging":             "defaults",
+		"production.settings": "defaults",
+		"hosts.1":             "primary",
+	}
+	if !reflect.DeepEqual(r.Aliases, expect) {
+		t.Errorf("Expected aliases %v, got %v", expect, r.Aliases)
+	}
+
+	staging, err := r.Resolved.Table("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging["image"] != "nginx" || staging["replicas"] != float64(1) {
+		t.Errorf("Unexpected resolved staging values: %v", staging)
+	}
+	if hosts := r.Resolved["hosts"].([]interface{}); hosts[1] != "example.com" {
+		t.Errorf("Expected alias in list to be resolved, got %v", hosts)
+	}
+}
+
+func TestReportAnchorsNone(t *testing.T) {
+	r, err := ReportAnchors(&chart.Config{Raw: "name: ahab\n"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.UsesAnchors() {
+		t.Errorf("Expected no anchors, got %v", r)
+	}
+	if r.Resolved != nil {
+		t.Errorf("Expected no resolved values, got %v", r.Resolved)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Masterminds/semver"
+	"github.com/golang/protobuf/proto"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// DependencyFetcher retrieves the packaged charts that a chart depends on.
+type DependencyFetcher interface {
+	// Fetch returns a chart archive (.tgz) for the named dependency.
+	//
+	// version is the version (range) given in the requirements, and repo is
+	// the repository URL. The caller closes the returned reader.
+	Fetch(name, version, repo string) (io.ReadCloser, error)
+}
+
+// BundleWithDependencies packages a chart together with all of its
+// dependencies, and returns the resulting .tgz archive.
+//
+// Each dependency listed in requirements.yaml (or, for apiVersion v2 charts,
+// in Chart.yaml) that has not already been loaded into c.Dependencies is
+// retrieved with fetcher. The fetched chart must have the requested name, and
+// a version satisfying the requested range. The bundled archive can then be
+// installed without access to any chart repository.
+//
+// c itself is not modified.
+func BundleWithDependencies(c *chart.Chart, fetcher DependencyFetcher) ([]byte, error) {
+	if c.Metadata == nil {
+		return nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+
+	deps, err := LoadDependencies(c)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", requirementsName, err)
+	}
+
+	nc := proto.Clone(c).(*chart.Chart)
+	have := map[string]bool{}
+	for _, dep := range nc.Dependencies {
+		if dep.Metadata != nil {
+			have[dep.Metadata.Name] = true
+		}
+	}
+
+	for _, d := range deps {
+		if have[d.Name] {
+			continue
+		}
+		dep, err := fetchDependency(fetcher, d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch dependency %q: %s", d.Name, err)
+		}
+		nc.Dependencies = append(nc.Dependencies, dep)
+		have[d.Name] = true
+	}
+
+	var buf bytes.Buffer
+	if err := SaveArchive(nc, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fetchDependency fetches and loads a single dependency, checking that it is
+// the chart that was asked for.
+func fetchDependency(fetcher DependencyFetcher, d *Dependency) (*chart.Chart, error) {
+	rc, err := fetcher.Fetch(d.Name, d.Version, d.Repository)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	dep, err := LoadArchive(rc)
+	if err != nil {
+		return nil, err
+	}
+	if dep.Metadata.Name != d.Name {
+		return nil, fmt.Errorf("fetched chart is named %q", dep.Metadata.Name)
+	}
+	if d.Version == "" {
+		return dep, nil
+	}
+	constraint, err := semver.NewConstraint(d.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q", d.Version)
+	}
+	v, err := semver.NewVersion(dep.Metadata.Version)
+	if err != nil || !constraint.Check(v) {
+		return nil, fmt.Errorf("fetched version %q does not satisfy %q", dep.Metadata.Version, d.Version)
+	}
+	return dep, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// testFetcher serves chart archives from a map of chart names to files.
+type testFetcher struct {
+	archives map[string]string
+	fetched  []string
+}
+
+func (f *testFetcher) Fetch(name, version, repo string) (io.ReadCloser, error) {
+	f.fetched = append(f.fetched, name+"-"+version)
+	file, ok := f.archives[name]
+	if !ok {
+		return nil, fmt.Errorf("chart %s not found in %s", name, repo)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func TestBundleWithDependencies(t *testing.T) {
+	full, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+
+	// Keep alpine, so that only mariner has to be fetched.
+	c := StripDependencies(full)
+	for _, dep := range full.Dependencies {
+		if dep.Metadata.Name == "alpine" {
+			c.Dependencies = append(c.Dependencies, dep)
+		}
+	}
+
+	fetcher := &testFetcher{archives: map[string]string{
+		"mariner": "testdata/frobnitz/charts/mariner-4.3.2.tgz",
+	}}
+	data, err := BundleWithDependencies(c, fetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetcher.fetched) != 1 || fetcher.fetched[0] != "mariner-4.3.2" {
+		t.Errorf("Expected only mariner-4.3.2 to be fetched, got %v", fetcher.fetched)
+	}
+	if len(c.Dependencies) != 1 {
+		t.Errorf("Expected the original chart to be unchanged, got %d dependencies", len(c.Dependencies))
+	}
+
+	bundled, err := LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to load bundle: %s", err)
+	}
+	if bundled.Metadata.Name != "frobnitz" {
+		t.Errorf("Expected frobnitz, got %s", bundled.Metadata.Name)
+	}
+	names := map[string]bool{}
+	for _, dep := range bundled.Dependencies {
+		names[dep.Metadata.Name] = true
+	}
+	if len(names) != 2 || !names["alpine"] || !names["mariner"] {
+		t.Errorf("Expected alpine and mariner in the bundle, got %v", names)
+	}
+}
+
+func TestBundleWithDependenciesMismatch(t *testing.T) {
+	full, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	c := StripDependencies(full)
+
+	fetcher := &testFetcher{archives: map[string]string{
+		"alpine":  "testdata/frobnitz/charts/mariner-4.3.2.tgz",
+		"mariner": "testdata/frobnitz/charts/mariner-4.3.2.tgz",
+	}}
+	if _, err := BundleWithDependencies(c, fetcher); err == nil {
+		t.Error("Expected an error when the fetched chart has the wrong name")
+	}
+
+	fetcher = &testFetcher{}
+	if _, err := BundleWithDependencies(c, fetcher); err == nil {
+		t.Error("Expected an error when a dependency cannot be fetched")
+	}
+}
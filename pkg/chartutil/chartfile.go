@@ -17,9 +17,12 @@ limitations under the License.
 package chartutil
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/ghodss/yaml"
+	yamlv2 "gopkg.in/yaml.v2"
 
 	"k8s.io/helm/pkg/proto/hapi/chart"
 )
@@ -29,10 +32,89 @@ import (
 // This is ApiVersionV1 instead of APIVersionV1 to match the protobuf-generated name.
 const ApiVersionV1 = "v1"
 
-// UnmarshalChartfile takes raw Chart.yaml data and unmarshals it.
+// ApiVersionV2 is the API version number for version 2.
+const ApiVersionV2 = "v2"
+
+// APIVersion returns the apiVersion of a chart.
+//
+// Charts that do not declare an apiVersion are treated as ApiVersionV1.
+func APIVersion(c *chart.Chart) string {
+	if c.Metadata == nil || c.Metadata.ApiVersion == "" {
+		return ApiVersionV1
+	}
+	return c.Metadata.ApiVersion
+}
+
+// ChartfileUnmarshaler is the function UnmarshalChartfile uses to parse
+// Chart.yaml data into a *chart.Metadata.
+//
+// The default is github.com/ghodss/yaml, which has the same JSON-compatible
+// semantics as sigs.k8s.io/yaml: YAML is converted to JSON, guided by the
+// type of each field, so an unquoted number given for a string field is
+// converted from its numeric value. A version of 1.10 becomes "1.1", not
+// "1.10". Set it to UnmarshalYAMLv2 for the semantics of gopkg.in/yaml.v2,
+// which rejects such a value, or to another parser. It should be set before
+// any charts are loaded, as it is not safe to change concurrently with
+// loading.
+var ChartfileUnmarshaler = yaml.Unmarshal
+
+// UnmarshalYAMLv2 parses YAML data with gopkg.in/yaml.v2, and then stores it
+// in v as encoding/json would, so that the json tags of v's fields apply.
+//
+// Unlike the default ChartfileUnmarshaler, scalars are typed by yaml.v2 alone,
+// so an unquoted number or boolean given for a string field is an error
+// rather than being converted.
+func UnmarshalYAMLv2(data []byte, v interface{}) error {
+	var raw interface{}
+	if err := yamlv2.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	converted, err := convertYAMLv2(raw)
+	if err != nil {
+		return err
+	}
+	j, err := json.Marshal(converted)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(j, v)
+}
+
+// convertYAMLv2 converts the map[interface{}]interface{} values produced by
+// yaml.v2 into map[string]interface{}, which encoding/json can marshal.
+func convertYAMLv2(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported map key %v of type %T", k, k)
+			}
+			cv, err := convertYAMLv2(val)
+			if err != nil {
+				return nil, err
+			}
+			m[ks] = cv
+		}
+		return m, nil
+	case []interface{}:
+		for i, e := range v {
+			ce, err := convertYAMLv2(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = ce
+		}
+	}
+	return v, nil
+}
+
+// UnmarshalChartfile takes raw Chart.yaml data and unmarshals it with
+// ChartfileUnmarshaler.
 func UnmarshalChartfile(data []byte) (*chart.Metadata, error) {
 	y := &chart.Metadata{}
-	err := yaml.Unmarshal(data, y)
+	err := ChartfileUnmarshaler(data, y)
 	if err != nil {
 		return nil, err
 	}
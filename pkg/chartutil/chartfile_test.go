@@ -33,6 +33,39 @@ func TestLoadChartfile(t *testing.T) {
 	verifyChartfile(t, f)
 }
 
+func TestChartfileUnmarshaler(t *testing.T) {
+	defer func(u func([]byte, interface{}) error) { ChartfileUnmarshaler = u }(ChartfileUnmarshaler)
+
+	data := []byte("name: ahab\nversion: 1.10\n")
+
+	m, err := UnmarshalChartfile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != "1.1" {
+		t.Errorf("Expected version to be converted from a number, got %q", m.Version)
+	}
+
+	ChartfileUnmarshaler = UnmarshalYAMLv2
+	if _, err := UnmarshalChartfile(data); err == nil {
+		t.Error("Expected yaml.v2 to reject a number for the version")
+	}
+
+	m, err = UnmarshalChartfile([]byte("name: ahab\nversion: \"1.0\"\napiVersion: v2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "ahab" || m.Version != "1.0" || m.ApiVersion != ApiVersionV2 {
+		t.Errorf("Unexpected metadata: %v", m)
+	}
+
+	f, err := LoadChartfile(testfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyChartfile(t, f)
+}
+
 func verifyChartfile(t *testing.T, f *chart.Metadata) {
 
 	if f == nil {
@@ -0,0 +1,98 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// The keys of the ConfigMap data produced by ToConfigMap.
+const (
+	ConfigMapChartKey     = "chart"
+	ConfigMapNameKey      = "name"
+	ConfigMapNamespaceKey = "namespace"
+)
+
+// ToConfigMap encodes a chart as the data of a ConfigMap.
+//
+// The chart is stored the same way Tiller stores releases: as a
+// gzip-compressed, base64-encoded protobuf, under the "chart" key. name and
+// namespace are the name and namespace of the ConfigMap, and are recorded
+// alongside it.
+func ToConfigMap(c *chart.Chart, name, namespace string) (map[string]string, error) {
+	if c.Metadata == nil {
+		return nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+	b, err := proto.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		ConfigMapChartKey:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+		ConfigMapNameKey:      name,
+		ConfigMapNamespaceKey: namespace,
+	}, nil
+}
+
+// FromConfigMap decodes a chart from ConfigMap data written by ToConfigMap.
+func FromConfigMap(data map[string]string) (*chart.Chart, error) {
+	encoded, ok := data[ConfigMapChartKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap has no %q key", ConfigMapChartKey)
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if b, err = ioutil.ReadAll(r); err != nil {
+		return nil, err
+	}
+
+	c := &chart.Chart{}
+	if err := proto.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestConfigMap(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+
+	data, err := ToConfigMap(c, "frobnitz.v1", "kube-system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[ConfigMapNameKey] != "frobnitz.v1" || data[ConfigMapNamespaceKey] != "kube-system" {
+		t.Errorf("Unexpected name and namespace in %v", data)
+	}
+
+	c2, err := FromConfigMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(c, c2) {
+		t.Error("Expected the chart to survive a round trip")
+	}
+	verifyFrobnitz(t, c2)
+
+	if _, err := FromConfigMap(map[string]string{}); err == nil {
+		t.Error("Expected an error for a missing chart key")
+	}
+	if _, err := FromConfigMap(map[string]string{ConfigMapChartKey: "bm90IGd6aXA="}); err == nil {
+		t.Error("Expected an error for data that is not gzipped")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// ContentType is a kind of file found in a chart, as classified by
+// ContentTypes.
+type ContentType string
+
+const (
+	// ContentManifest is a plain Kubernetes manifest, with no template actions.
+	ContentManifest ContentType = "kubernetes-manifest"
+	// ContentTemplate is a file with template actions.
+	ContentTemplate ContentType = "helm-template"
+	// ContentBinary is a file that is not text.
+	ContentBinary ContentType = "binary"
+	// ContentText is any other text file, such as a README or a script.
+	ContentText ContentType = "text"
+	// ContentUnknown is an empty file that has no recognized extension.
+	ContentUnknown ContentType = "unknown"
+)
+
+// contentTypeOrder is the order in which ties for the dominant type are
+// broken.
+var contentTypeOrder = []ContentType{ContentManifest, ContentTemplate, ContentBinary, ContentText, ContentUnknown}
+
+var (
+	// manifestAPIVersion and manifestKind match the top-level fields of a
+	// Kubernetes manifest.
+	manifestAPIVersion = regexp.MustCompile(`(?m)^apiVersion:[ \t]*\S`)
+	manifestKind       = regexp.MustCompile(`(?m)^kind:[ \t]*\S`)
+
+	// binaryExtensions and textExtensions classify a file by its extension
+	// when its content does not settle the question.
+	binaryExtensions = map[string]bool{
+		".gz": true, ".tgz": true, ".tar": true, ".zip": true, ".jar": true,
+		".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".ico": true,
+		".so": true, ".exe": true, ".bin": true,
+	}
+	textExtensions = map[string]bool{
+		".txt": true, ".md": true, ".yaml": true, ".yml": true, ".json": true,
+		".tpl": true, ".sh": true, ".py": true, ".conf": true, ".toml": true,
+		".prov": true, ".properties": true,
+	}
+)
+
+// ContentTypeSummary describes the kinds of files in a chart.
+type ContentTypeSummary struct {
+	// Files maps the name of each template and file to its type.
+	Files map[string]ContentType
+	// Counts is the number of files of each type.
+	Counts map[ContentType]int
+	// Dominant is the most common type, or ContentUnknown if the chart has
+	// no templates or files. A tie goes to the type listed first among the
+	// ContentType constants.
+	Dominant ContentType
+}
+
+// ContentTypes classifies the templates and files of a chart, so that linters
+// can tell a chart of Kubernetes resources from one that carries data files,
+// scripts, or binaries.
+//
+// A file is classified by its content first and its extension second: a file
+// that is not valid UTF-8 or contains a NUL byte is binary, one with template
+// actions ({{) is a template, and YAML with a top-level apiVersion and kind
+// is a manifest. Dependencies are not included. Chart.yaml and values.yaml
+// are not templates or files, so they are not counted.
+func ContentTypes(c *chart.Chart) ContentTypeSummary {
+	s := ContentTypeSummary{
+		Files:    map[string]ContentType{},
+		Counts:   map[ContentType]int{},
+		Dominant: ContentUnknown,
+	}
+	for _, t := range c.Templates {
+		s.Files[t.Name] = classifyContent(t.Name, t.Data)
+	}
+	for _, f := range c.Files {
+		s.Files[f.TypeUrl] = classifyContent(f.TypeUrl, f.Value)
+	}
+
+	for _, ct := range s.Files {
+		s.Counts[ct]++
+	}
+	max := 0
+	for _, ct := range contentTypeOrder {
+		if s.Counts[ct] > max {
+			max = s.Counts[ct]
+			s.Dominant = ct
+		}
+	}
+	return s
+}
+
+// classifyContent returns the type of a file from its name and content.
+func classifyContent(name string, data []byte) ContentType {
+	ext := strings.ToLower(path.Ext(name))
+	if binaryExtensions[ext] || bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return ContentBinary
+	}
+	if bytes.Contains(data, []byte("{{")) {
+		return ContentTemplate
+	}
+	if (ext == ".yaml" || ext == ".yml") && manifestAPIVersion.Match(data) && manifestKind.Match(data) {
+		return ContentManifest
+	}
+	if len(bytes.TrimSpace(data)) == 0 && !textExtensions[ext] {
+		return ContentUnknown
+	}
+	return ContentText
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestContentTypes(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab"},
+		Templates: []*chart.Template{
+			{Name: "templates/deployment.yaml", Data: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ .Release.Name }}\n")},
+			{Name: "templates/service.yaml", Data: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: pequod\n")},
+			{Name: "templates/NOTES.txt", Data: []byte("Thar she blows!\n")},
+		},
+		Files: []*any.Any{
+			{TypeUrl: "README.md", Value: []byte("# Ahab\n")},
+			{TypeUrl: "scripts/hunt.sh", Value: []byte("#!/bin/sh\necho whale\n")},
+			{TypeUrl: "files/logo.png", Value: []byte("\x89PNG\r\n\x1a\n")},
+			{TypeUrl: "files/harpoon", Value: []byte{0x7f, 'E', 'L', 'F', 0, 0}},
+			{TypeUrl: "files/blank", Value: []byte("\n")},
+		},
+	}
+
+	s := ContentTypes(c)
+	expectFiles := map[string]ContentType{
+		"templates/deployment.yaml": ContentTemplate,
+		"templates/service.yaml":    ContentManifest,
+		"templates/NOTES.txt":       ContentText,
+		"README.md":                 ContentText,
+		"scripts/hunt.sh":           ContentText,
+		"files/logo.png":            ContentBinary,
+		"files/harpoon":             ContentBinary,
+		"files/blank":               ContentUnknown,
+	}
+	if !reflect.DeepEqual(s.Files, expectFiles) {
+		t.Errorf("Expected %v, got %v", expectFiles, s.Files)
+	}
+	expectCounts := map[ContentType]int{
+		ContentTemplate: 1,
+		ContentManifest: 1,
+		ContentText:     3,
+		ContentBinary:   2,
+		ContentUnknown:  1,
+	}
+	if !reflect.DeepEqual(s.Counts, expectCounts) {
+		t.Errorf("Expected %v, got %v", expectCounts, s.Counts)
+	}
+	if s.Dominant != ContentText {
+		t.Errorf("Expected dominant type %s, got %s", ContentText, s.Dominant)
+	}
+
+	if s := ContentTypes(&chart.Chart{}); s.Dominant != ContentUnknown || len(s.Counts) != 0 {
+		t.Errorf("Expected an empty summary for an empty chart, got %+v", s)
+	}
+}
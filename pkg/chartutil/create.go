@@ -44,6 +44,10 @@ const (
 	NotesName = "NOTES.txt"
 	// HelpersName is the name of the example NOTES.txt file.
 	HelpersName = "_helpers.tpl"
+	// TestsDir is the relative directory name for test templates.
+	TestsDir = TemplatesDir + "/tests"
+	// TestConnectionName is the name of the example connection test file.
+	TestConnectionName = "test-connection.yaml"
 )
 
 const defaultValues = `# Default values for %s.
@@ -175,6 +179,43 @@ We truncate at 24 chars because some Kubernetes name fields are limited to this
 {{- end -}}
 `
 
+const defaultTestConnection = `apiVersion: v1
+kind: Pod
+metadata:
+  name: "{{ template "fullname" . }}-test-connection"
+  labels:
+    chart: "{{ .Chart.Name }}-{{ .Chart.Version }}"
+  annotations:
+    "helm.sh/hook": test-success
+spec:
+  containers:
+  - name: curl
+    image: appropriate/curl
+    command: ["curl"]
+    args: ["--fail", "--silent", "--show-error", %q]
+  restartPolicy: Never
+`
+
+// ScaffoldTest generates a test Pod template that curls the given endpoint.
+//
+// The template is named templates/tests/test-connection.yaml, and is annotated
+// as a helm test hook. It is returned, ready to be appended to c.Templates.
+//
+// If c already has a template by that name, nil is returned so that the
+// existing test is not overwritten.
+func ScaffoldTest(c *chart.Chart, endpoint string) *chart.Template {
+	name := TestsDir + "/" + TestConnectionName
+	for _, t := range c.Templates {
+		if t.Name == name {
+			return nil
+		}
+	}
+	return &chart.Template{
+		Name: name,
+		Data: []byte(fmt.Sprintf(defaultTestConnection, endpoint)),
+	}
+}
+
 // CreateFrom creates a new chart, but scaffolds it from the src chart.
 func CreateFrom(chartfile *chart.Metadata, dest string, src string) error {
 	schart, err := Load(src)
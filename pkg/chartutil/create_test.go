@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"k8s.io/helm/pkg/proto/hapi/chart"
@@ -126,3 +127,25 @@ func TestCreateFrom(t *testing.T) {
 		}
 	}
 }
+
+func TestScaffoldTest(t *testing.T) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "foo"}}
+
+	tpl := ScaffoldTest(c, "http://foo:80")
+	if tpl == nil {
+		t.Fatal("Expected a test template")
+	}
+	if tpl.Name != "templates/tests/test-connection.yaml" {
+		t.Errorf("Unexpected template name %q", tpl.Name)
+	}
+	for _, expect := range []string{`"helm.sh/hook": test-success`, `"http://foo:80"`} {
+		if !strings.Contains(string(tpl.Data), expect) {
+			t.Errorf("Expected template to contain %s:\n%s", expect, tpl.Data)
+		}
+	}
+
+	c.Templates = append(c.Templates, tpl)
+	if again := ScaffoldTest(c, "http://bar:80"); again != nil {
+		t.Errorf("Expected existing test template to be kept, got %q", again.Data)
+	}
+}
@@ -38,6 +38,10 @@ For accepting raw compressed tar file data from an io.Reader, the
 'chartutil.LoadArchive()' will read in the data, uncompress it, and unpack it
 into a Chart.
 
+Loading behavior can be tuned by passing LoadOption values, such as
+'chartutil.WithMaxSize()', to 'chartutil.LoadWithOptions()', 'chartutil.LoadDir()',
+'chartutil.LoadFile()', or 'chartutil.LoadArchive()'.
+
 When creating charts in memory, use the 'k8s.io/helm/pkg/proto/happy/chart'
 package directly.
 */
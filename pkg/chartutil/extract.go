@@ -0,0 +1,99 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// ExtractOptions selects the parts of a chart that Extract writes.
+type ExtractOptions struct {
+	// Templates writes the files under templates/.
+	Templates bool
+	// Values writes values.yaml.
+	Values bool
+	// Files writes the chart's other files, such as README.md or LICENSE.
+	Files bool
+	// Dependencies writes each dependency as an archive under charts/.
+	Dependencies bool
+}
+
+// Extract writes the selected parts of a chart into destDir, which is treated
+// as the root of the chart and created if it does not exist.
+//
+// Unlike SaveDir, Chart.yaml is never written, and nothing is written for a
+// part that is not selected. It is an error for a template or file name to
+// point outside of destDir.
+func Extract(c *chart.Chart, destDir string, opts ExtractOptions) error {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+
+	if opts.Values && c.Values != nil && len(c.Values.Raw) > 0 {
+		if err := extractFile(destDir, ValuesfileName, []byte(c.Values.Raw)); err != nil {
+			return err
+		}
+	}
+
+	if opts.Templates {
+		for _, f := range c.Templates {
+			if err := extractFile(destDir, f.Name, f.Data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Files {
+		for _, f := range c.Files {
+			if err := extractFile(destDir, f.TypeUrl, f.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Dependencies && len(c.Dependencies) > 0 {
+		base := filepath.Join(destDir, ChartsDir)
+		if err := os.MkdirAll(base, 0755); err != nil {
+			return err
+		}
+		for _, dep := range c.Dependencies {
+			if _, err := Save(dep, base); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// extractFile writes data to name under destDir, creating any missing
+// directories.
+func extractFile(destDir, name string, data []byte) error {
+	n := filepath.Join(destDir, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(destDir, n); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("chart file %q is outside of the chart", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(n), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(n, data, 0644)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestExtract(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab", Version: "1.2.3"},
+		Values:   &chart.Config{Raw: "ship: Pequod"},
+		Templates: []*chart.Template{
+			{Name: "templates/deployment.yaml", Data: []byte("kind: Deployment")},
+			{Name: "templates/crew/_helpers.tpl", Data: []byte(`{{ define "crew" }}{{ end }}`)},
+		},
+		Files: []*any.Any{
+			{TypeUrl: "README.md", Value: []byte("Call me Ishmael.")},
+		},
+		Dependencies: []*chart.Chart{
+			{Metadata: &chart.Metadata{Name: "starbuck", Version: "0.1.0"}},
+		},
+	}
+
+	dest := filepath.Join(tmp, "ahab")
+	if err := Extract(c, dest, ExtractOptions{Templates: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tpl := range c.Templates {
+		data, err := ioutil.ReadFile(filepath.Join(dest, tpl.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(tpl.Data) {
+			t.Errorf("Expected %s to contain %q, got %q", tpl.Name, tpl.Data, data)
+		}
+	}
+	for _, n := range []string{ChartfileName, ValuesfileName, "README.md", ChartsDir} {
+		if _, err := os.Stat(filepath.Join(dest, n)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be extracted", n)
+		}
+	}
+
+	c.Templates = append(c.Templates, &chart.Template{Name: "../escape.yaml"})
+	if err := Extract(c, dest, ExtractOptions{Templates: true}); err == nil {
+		t.Error("Expected an error for a template outside of the chart")
+	}
+}
@@ -16,8 +16,14 @@ limitations under the License.
 package chartutil
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/gobwas/glob"
 	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
 )
 
 // Files is a map of files in a chart that can be accessed from a template.
@@ -83,3 +89,91 @@ func (f Files) Glob(pattern string) Files {
 
 	return nf
 }
+
+// FindFiles returns the files in a chart whose names match pattern.
+//
+// The pattern is a glob, in which * does not match /. A pattern ending in /
+// matches every file under that directory.
+func FindFiles(c *chart.Chart, pattern string) []*any.Any {
+	found := []*any.Any{}
+	if strings.HasSuffix(pattern, "/") {
+		for _, f := range c.Files {
+			if strings.HasPrefix(f.TypeUrl, pattern) {
+				found = append(found, f)
+			}
+		}
+		return found
+	}
+
+	g, err := glob.Compile(pattern, '/')
+	if err != nil {
+		return found
+	}
+	for _, f := range c.Files {
+		if g.Match(f.TypeUrl) {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+// GetFile returns the contents of the named file in a chart, and whether it exists.
+func GetFile(c *chart.Chart, name string) ([]byte, bool) {
+	for _, f := range c.Files {
+		if f.TypeUrl == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// readmeNames are the names Readme looks for, in order of preference.
+var readmeNames = []string{ReadmeName, "README.txt", "README"}
+
+// Readme returns the contents of a chart's top-level README, and whether
+// there is one.
+//
+// README.md is preferred, followed by README.txt and then README. Names are
+// matched without regard to case, so readme.md is found as README.md.
+func Readme(c *chart.Chart) (string, bool) {
+	for _, name := range readmeNames {
+		for _, f := range c.Files {
+			if strings.EqualFold(f.TypeUrl, name) {
+				return string(f.Value), true
+			}
+		}
+	}
+	return "", false
+}
+
+// filesReference matches a call to .Files.Get, .Files.GetBytes, or .Files.Glob
+// with a string literal argument.
+var filesReference = regexp.MustCompile("\\.Files\\.(Get|GetBytes|Glob)\\s+(?:\"([^\"]*)\"|`([^`]*)`)")
+
+// CheckFileReferences finds references in a chart's templates to files that
+// are not in the chart.
+//
+// Templates are searched for .Files.Get, .Files.GetBytes, and .Files.Glob
+// calls whose argument is a string literal. A glob is reported if it matches
+// no files. Names that are computed at render time cannot be checked.
+// Dependencies are not searched.
+//
+// Each problem is described in the form "templates/cm.yaml: .Files.Get
+// "app.conf": file not found".
+func CheckFileReferences(c *chart.Chart) []string {
+	files := NewFiles(c.Files)
+	problems := []string{}
+	for _, t := range c.Templates {
+		for _, m := range filesReference.FindAllStringSubmatch(string(t.Data), -1) {
+			fn, name := m[1], m[2]+m[3]
+			if fn == "Glob" {
+				if len(files.Glob(name)) == 0 {
+					problems = append(problems, fmt.Sprintf("%s: .Files.Glob %q: no files match", t.Name, name))
+				}
+			} else if _, ok := files[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: .Files.%s %q: file not found", t.Name, fn, name))
+			}
+		}
+	}
+	return problems
+}
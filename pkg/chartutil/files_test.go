@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
 )
 
 var cases = []struct {
@@ -68,3 +70,91 @@ func TestFileGlob(t *testing.T) {
 		t.Errorf("Wrong globbed file content. Expected %s, got %s", expect, m)
 	}
 }
+
+func TestFindFiles(t *testing.T) {
+	c := &chart.Chart{Files: getTestFiles()}
+
+	tests := []struct {
+		pattern string
+		expect  int
+	}{
+		{"story/", 2},
+		{"story/*.txt", 2},
+		{"*/captain.txt", 1},
+		{"*.txt", 0},
+		{"**.txt", 4},
+		{"ship/nobody.txt", 0},
+	}
+	for _, tt := range tests {
+		if got := FindFiles(c, tt.pattern); len(got) != tt.expect {
+			t.Errorf("%s: expected %d files, got %d", tt.pattern, tt.expect, len(got))
+		}
+	}
+}
+
+func TestGetFile(t *testing.T) {
+	c := &chart.Chart{Files: getTestFiles()}
+
+	data, ok := GetFile(c, "story/author.txt")
+	if !ok {
+		t.Fatal("Expected story/author.txt to exist")
+	}
+	if string(data) != "Joseph Conrad" {
+		t.Errorf("Expected Joseph Conrad, got %q", data)
+	}
+
+	if _, ok := GetFile(c, "story/editor.txt"); ok {
+		t.Error("Expected story/editor.txt not to exist")
+	}
+}
+
+func TestReadme(t *testing.T) {
+	c := &chart.Chart{Files: getTestFiles()}
+	if _, ok := Readme(c); ok {
+		t.Error("Expected no README")
+	}
+
+	c.Files = append(c.Files,
+		&any.Any{TypeUrl: "docs/README.md", Value: []byte("Not top-level")},
+		&any.Any{TypeUrl: "README.txt", Value: []byte("Plain text")},
+	)
+	if readme, ok := Readme(c); !ok || readme != "Plain text" {
+		t.Errorf("Expected README.txt, got %q", readme)
+	}
+
+	c.Files = append(c.Files, &any.Any{TypeUrl: "readme.md", Value: []byte("# The Secret Sharer")})
+	if readme, ok := Readme(c); !ok || readme != "# The Secret Sharer" {
+		t.Errorf("Expected README.md to be preferred, got %q", readme)
+	}
+}
+
+func TestCheckFileReferences(t *testing.T) {
+	c := &chart.Chart{
+		Files: getTestFiles(),
+		Templates: []*chart.Template{
+			{Name: "templates/cm.yaml", Data: []byte(`data:
+  author: {{ .Files.Get "story/author.txt" | quote }}
+  editor: {{ $.Files.Get "story/editor.txt" | quote }}
+  ship: {{ .Files.GetBytes ` + "`ship/firstmate.txt`" + ` | b64enc }}
+{{ range $path, $_ := .Files.Glob "ship/*.txt" }}  {{ $path }}: ok{{ end }}
+{{ range $path, $_ := .Files.Glob "cargo/**" }}  {{ $path }}: ok{{ end }}
+  computed: {{ .Files.Get .Values.file }}
+`)},
+		},
+	}
+
+	expect := []string{
+		`templates/cm.yaml: .Files.Get "story/editor.txt": file not found`,
+		`templates/cm.yaml: .Files.GetBytes "ship/firstmate.txt": file not found`,
+		`templates/cm.yaml: .Files.Glob "cargo/**": no files match`,
+	}
+	problems := CheckFileReferences(c)
+	if len(problems) != len(expect) {
+		t.Fatalf("Expected %d problems, got %v", len(expect), problems)
+	}
+	for i, p := range expect {
+		if problems[i] != p {
+			t.Errorf("Expected %q, got %q", p, problems[i])
+		}
+	}
+}
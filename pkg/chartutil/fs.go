@@ -0,0 +1,214 @@
+//go:build go1.16
+// +build go1.16
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// ChartFS returns a read-only filesystem view of a loaded chart.
+//
+// The filesystem is laid out the same way as a chart directory: Chart.yaml,
+// values.yaml, templates/, other files, and a directory under charts/ for each
+// dependency. Chart.yaml is re-serialized from the chart's metadata.
+//
+// The returned fs.FS also implements fs.ReadFileFS and fs.ReadDirFS. It
+// requires Go 1.16 or later.
+func ChartFS(c *chart.Chart) fs.FS {
+	cfs := &chartFS{
+		files: map[string][]byte{},
+		dirs:  map[string]map[string]bool{".": {}},
+	}
+	cfs.addChart(c, "")
+	return cfs
+}
+
+// chartFS is an in-memory fs.FS over the contents of a chart.
+type chartFS struct {
+	files map[string][]byte
+	// dirs maps each directory to the set of its children's names.
+	dirs map[string]map[string]bool
+}
+
+func (cfs *chartFS) addChart(c *chart.Chart, prefix string) {
+	if c.Metadata != nil {
+		if b, err := yaml.Marshal(c.Metadata); err == nil {
+			cfs.add(path.Join(prefix, ChartfileName), b)
+		}
+	}
+	if c.Values != nil {
+		cfs.add(path.Join(prefix, ValuesfileName), []byte(c.Values.Raw))
+	}
+	for _, t := range c.Templates {
+		cfs.add(path.Join(prefix, t.Name), t.Data)
+	}
+	for _, f := range c.Files {
+		cfs.add(path.Join(prefix, f.TypeUrl), f.Value)
+	}
+	for _, dep := range c.Dependencies {
+		if dep.Metadata == nil {
+			continue
+		}
+		cfs.addChart(dep, path.Join(prefix, ChartsDir, dep.Metadata.Name))
+	}
+}
+
+// add records a file, along with each of its parent directories.
+func (cfs *chartFS) add(name string, data []byte) {
+	if !fs.ValidPath(name) {
+		return
+	}
+	cfs.files[name] = data
+	for name != "." {
+		dir := path.Dir(name)
+		if cfs.dirs[dir] == nil {
+			cfs.dirs[dir] = map[string]bool{}
+		}
+		cfs.dirs[dir][path.Base(name)] = true
+		name = dir
+	}
+}
+
+// Open implements fs.FS.
+func (cfs *chartFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if data, ok := cfs.files[name]; ok {
+		return &chartFile{info: fileInfo{name: path.Base(name), size: int64(len(data))}, r: bytes.NewReader(data)}, nil
+	}
+	if _, ok := cfs.dirs[name]; ok {
+		entries, _ := cfs.ReadDir(name)
+		return &chartDir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (cfs *chartFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	data, ok := cfs.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (cfs *chartFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	children, ok := cfs.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	names := make([]string, 0, len(children))
+	for n := range children {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, n := range names {
+		full := path.Join(name, n)
+		if data, ok := cfs.files[full]; ok {
+			entries = append(entries, fileInfo{name: n, size: int64(len(data))})
+		} else {
+			entries = append(entries, fileInfo{name: n, dir: true})
+		}
+	}
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo and fs.DirEntry for chartFS entries.
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi fileInfo) Name() string               { return fi.name }
+func (fi fileInfo) Size() int64                { return fi.size }
+func (fi fileInfo) ModTime() time.Time         { return time.Time{} }
+func (fi fileInfo) IsDir() bool                { return fi.dir }
+func (fi fileInfo) Sys() interface{}           { return nil }
+func (fi fileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// chartFile is an open regular file in a chartFS.
+type chartFile struct {
+	info fileInfo
+	r    *bytes.Reader
+}
+
+func (f *chartFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *chartFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *chartFile) Close() error               { return nil }
+
+// chartDir is an open directory in a chartFS.
+type chartDir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *chartDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *chartDir) Close() error               { return nil }
+
+func (d *chartDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *chartDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
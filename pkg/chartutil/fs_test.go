@@ -0,0 +1,74 @@
+//go:build go1.16
+// +build go1.16
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestChartFS(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	cfs := ChartFS(c)
+
+	expect := []string{
+		"Chart.yaml",
+		"values.yaml",
+		"templates/template.tpl",
+		"docs/README.md",
+		"charts/alpine/Chart.yaml",
+		"charts/alpine/charts/mast1/values.yaml",
+		"charts/mariner/templates/placeholder.tpl",
+	}
+	if err := fstest.TestFS(cfs, expect...); err != nil {
+		t.Fatal(err)
+	}
+
+	walked := map[string]bool{}
+	err = fs.WalkDir(cfs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			walked[name] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range expect {
+		if !walked[name] {
+			t.Errorf("Expected WalkDir to visit %s", name)
+		}
+	}
+
+	data, err := fs.ReadFile(cfs, "templates/template.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(c.Templates[0].Data) {
+		t.Errorf("Expected template data %q, got %q", c.Templates[0].Data, data)
+	}
+}
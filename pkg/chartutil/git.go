@@ -0,0 +1,81 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DirtyWorkingTreeError indicates that a chart directory has changes that are
+// not committed to git.
+type DirtyWorkingTreeError struct {
+	// Dir is the chart directory.
+	Dir string
+	// Files lists the changed files, as reported by 'git status --short'.
+	Files []string
+}
+
+func (e *DirtyWorkingTreeError) Error() string {
+	return fmt.Sprintf("chart directory %s has uncommitted changes: %s", e.Dir, strings.Join(e.Files, ", "))
+}
+
+// checkGitClean returns a DirtyWorkingTreeError if dir is in a git working
+// tree and has uncommitted changes.
+func checkGitClean(dir string) error {
+	if !inGitRepo(dir) {
+		return nil
+	}
+
+	cmd := exec.Command("git", "status", "--short", "--", ".")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git status failed in %s: %s %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	if len(files) > 0 {
+		return &DirtyWorkingTreeError{Dir: dir, Files: files}
+	}
+	return nil
+}
+
+// inGitRepo reports whether dir or one of its parents contains a .git entry.
+func inGitRepo(dir string) bool {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDirGitDirtyCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmp
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+
+	dir := filepath.Join(tmp, "ahab")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	chartfile := filepath.Join(dir, ChartfileName)
+	if err := ioutil.WriteFile(chartfile, []byte("name: ahab\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not a git repository yet, so there is nothing to check.
+	if _, err := LoadDir(dir, WithGitDirtyCheck()); err != nil {
+		t.Fatalf("Expected chart outside of git to load: %s", err)
+	}
+
+	git("init", "-q")
+	git("-c", "user.name=Helm", "-c", "user.email=helm@example.com", "add", ".")
+	git("-c", "user.name=Helm", "-c", "user.email=helm@example.com", "commit", "-q", "-m", "Add ahab")
+
+	if _, err := LoadDir(dir, WithGitDirtyCheck()); err != nil {
+		t.Fatalf("Expected clean chart to load: %s", err)
+	}
+
+	if err := ioutil.WriteFile(chartfile, []byte("name: ahab\nversion: 0.2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ValuesfileName), []byte("whale: white\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadDir(dir, WithGitDirtyCheck())
+	dirty, ok := err.(*DirtyWorkingTreeError)
+	if !ok {
+		t.Fatalf("Expected DirtyWorkingTreeError, got %T: %v", err, err)
+	}
+	if len(dirty.Files) != 2 {
+		t.Errorf("Expected 2 changed files, got %v", dirty.Files)
+	}
+
+	if _, err := LoadDir(dir); err != nil {
+		t.Errorf("Expected the check to be opt-in: %s", err)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// ContentHash returns a SHA-256 digest, in hex, of the contents of a chart.
+//
+// Unlike a digest of a chart archive, this does not depend on how the chart
+// was packaged: a chart loaded from a directory has the same ContentHash as
+// the same chart loaded from an archive. The digest covers Chart.yaml,
+// values.yaml, and every template and file, in order of name, as well as the
+// ContentHash of each dependency.
+func ContentHash(c *chart.Chart) string {
+	entries := map[string][]byte{}
+	if c.Metadata != nil {
+		// Errors are impossible here, since Metadata is a plain struct.
+		entries[ChartfileName], _ = yaml.Marshal(c.Metadata)
+	}
+	if c.Values != nil {
+		entries[ValuesfileName] = []byte(c.Values.Raw)
+	}
+	for _, t := range c.Templates {
+		entries[t.Name] = t.Data
+	}
+	for _, f := range c.Files {
+		entries[f.TypeUrl] = f.Value
+	}
+
+	deps := make([]string, 0, len(c.Dependencies))
+	for _, dep := range c.Dependencies {
+		deps = append(deps, ContentHash(dep))
+	}
+	sort.Strings(deps)
+
+	names := make([]string, 0, len(entries))
+	for n := range entries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, n := range names {
+		writeHashEntry(h, n, entries[n])
+	}
+	for _, d := range deps {
+		writeHashEntry(h, ChartsDir+"/", []byte(d))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChartsEqual reports whether a and b have the same content: the same
+// metadata, values, templates, and files, and equal dependencies.
+//
+// Templates, files, and dependencies are compared as sets, so the order in
+// which they were loaded, and whether the charts came from directories or
+// archives, makes no difference. Two charts are equal exactly when they have
+// the same ContentHash.
+func ChartsEqual(a, b *chart.Chart) bool {
+	return ContentHash(a) == ContentHash(b)
+}
+
+// HashTemplates returns a SHA-256 digest, in hex, of the templates of a chart
+// and its dependencies.
+//
+// Only template names and contents are hashed, so charts that differ only in
+// their metadata or values, such as two versions of a chart that changed only
+// its version number, have the same HashTemplates. Dependencies' templates are
+// hashed under their path from the top chart, as in
+// charts/alpine/templates/pod.yaml. An error is returned if two templates
+// have the same path.
+func HashTemplates(c *chart.Chart) (string, error) {
+	entries := map[string][]byte{}
+	if err := collectTemplates(c, "", entries); err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(entries))
+	for n := range entries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, n := range names {
+		writeHashEntry(h, n, entries[n])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// collectTemplates adds the templates of c and its dependencies to entries,
+// keyed by their path below prefix.
+func collectTemplates(c *chart.Chart, prefix string, entries map[string][]byte) error {
+	for _, t := range c.Templates {
+		n := prefix + t.Name
+		if _, ok := entries[n]; ok {
+			return fmt.Errorf("duplicate template %s", n)
+		}
+		entries[n] = t.Data
+	}
+	for _, dep := range c.Dependencies {
+		if dep.Metadata == nil {
+			return fmt.Errorf("dependency of %s has no metadata", prefix+ChartfileName)
+		}
+		if err := collectTemplates(dep, prefix+ChartsDir+"/"+dep.Metadata.Name+"/", entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyPackaged compares the chart in dir with the packaged chart in archive,
+// returning the paths of the files that differ between them.
+//
+// A file is reported if it is missing from either chart or has different
+// contents. Dependencies are compared file by file, under
+// charts/NAME/. Chart.yaml is compared by its parsed contents, so formatting
+// differences are ignored. The paths are sorted. Files ignored by the chart's
+// .helmignore are not considered. This is useful in CI, to check that a
+// committed archive is not stale.
+func VerifyPackaged(dir, archive string) ([]string, error) {
+	src, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := LoadFile(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	want := map[string][]byte{}
+	flattenChart(src, "", want)
+	got := map[string][]byte{}
+	flattenChart(pkg, "", got)
+
+	var drifted []string
+	for n, data := range want {
+		if other, ok := got[n]; !ok || !bytes.Equal(data, other) {
+			drifted = append(drifted, n)
+		}
+	}
+	for n := range got {
+		if _, ok := want[n]; !ok {
+			drifted = append(drifted, n)
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// flattenChart adds every file of c and its dependencies to files, keyed by
+// their path below prefix.
+func flattenChart(c *chart.Chart, prefix string, files map[string][]byte) {
+	if c.Metadata != nil {
+		// Errors are impossible here, since Metadata is a plain struct.
+		files[prefix+ChartfileName], _ = yaml.Marshal(c.Metadata)
+	}
+	if c.Values != nil {
+		files[prefix+ValuesfileName] = []byte(c.Values.Raw)
+	}
+	for _, t := range c.Templates {
+		files[prefix+t.Name] = t.Data
+	}
+	for _, f := range c.Files {
+		files[prefix+f.TypeUrl] = f.Value
+	}
+	for _, dep := range c.Dependencies {
+		name := ""
+		if dep.Metadata != nil {
+			name = dep.Metadata.Name
+		}
+		flattenChart(dep, prefix+ChartsDir+"/"+name+"/", files)
+	}
+}
+
+// writeHashEntry writes a name and its data to h, each prefixed by its length
+// so that different entries can never produce the same input.
+func writeHashEntry(h hash.Hash, name string, data []byte) {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(name)))
+	h.Write(size[:])
+	h.Write([]byte(name))
+	binary.BigEndian.PutUint64(size[:], uint64(len(data)))
+	h.Write(size[:])
+	h.Write(data)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestContentHash(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	archive, err := Save(c, tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packaged, err := Load(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := ContentHash(c)
+	if len(sum) != 64 {
+		t.Errorf("Expected a hex SHA-256, got %q", sum)
+	}
+	if got := ContentHash(packaged); got != sum {
+		t.Errorf("Expected directory and archive to have the same hash, got %s and %s", sum, got)
+	}
+
+	c.Templates[0].Data = append(c.Templates[0].Data, '\n')
+	if ContentHash(c) == sum {
+		t.Error("Expected the hash to change with a template")
+	}
+}
+
+func TestChartsEqual(t *testing.T) {
+	a, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	b := proto.Clone(a).(*chart.Chart)
+
+	// Reverse the order of everything that is compared as a set.
+	for i, j := 0, len(b.Templates)-1; i < j; i, j = i+1, j-1 {
+		b.Templates[i], b.Templates[j] = b.Templates[j], b.Templates[i]
+	}
+	for i, j := 0, len(b.Files)-1; i < j; i, j = i+1, j-1 {
+		b.Files[i], b.Files[j] = b.Files[j], b.Files[i]
+	}
+	for i, j := 0, len(b.Dependencies)-1; i < j; i, j = i+1, j-1 {
+		b.Dependencies[i], b.Dependencies[j] = b.Dependencies[j], b.Dependencies[i]
+	}
+	if !ChartsEqual(a, b) {
+		t.Error("Expected reordered charts to be equal")
+	}
+
+	// A single byte in a dependency's file makes a difference.
+	dep := b.Dependencies[0]
+	if len(dep.Files) == 0 || len(dep.Files[0].Value) == 0 {
+		t.Fatalf("Expected %s to have a file", dep.Metadata.Name)
+	}
+	dep.Files[0].Value[0]++
+	if ChartsEqual(a, b) {
+		t.Error("Expected charts differing in one byte to be unequal")
+	}
+}
+
+func TestHashTemplates(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	sum, err := HashTemplates(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Metadata.Version = "9.9.9"
+	c.Metadata.Description = "A different release"
+	c.Values.Raw += "\nextra: true\n"
+	if got, err := HashTemplates(c); err != nil || got != sum {
+		t.Errorf("Expected the hash to ignore metadata and values, got %s and %s (%v)", sum, got, err)
+	}
+
+	dep := c.Dependencies[0]
+	dep.Templates[0].Data = append(dep.Templates[0].Data, '\n')
+	if got, _ := HashTemplates(c); got == sum {
+		t.Error("Expected the hash to change with a dependency's template")
+	}
+
+	c.Templates = append(c.Templates, c.Templates[0])
+	if _, err := HashTemplates(c); err == nil {
+		t.Error("Expected an error for duplicate templates")
+	}
+}
+
+func TestVerifyPackaged(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "ahab")
+	files := map[string]string{
+		"Chart.yaml":              "name: ahab\nversion: 0.1.0\n",
+		"values.yaml":             "captain: ahab\n",
+		"templates/ship.yaml":     "kind: Ship\n",
+		"charts/whale/Chart.yaml": "name: whale\nversion: 0.1.0\n",
+	}
+	for n, data := range files {
+		p := filepath.Join(dir, n)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := Save(c, tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drifted, err := VerifyPackaged(dir, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drifted) != 0 {
+		t.Errorf("Expected no drift, got %v", drifted)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "templates/ship.yaml"), []byte("kind: Whaler\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "charts/whale/NOTES.txt"), []byte("Thar she blows\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	drifted, err = VerifyPackaged(dir, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"charts/whale/NOTES.txt", "templates/ship.yaml"}; !reflect.DeepEqual(drifted, expect) {
+		t.Errorf("Expected drift in %v, got %v", expect, drifted)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// ImageRef is a container image referenced by a rendered template.
+type ImageRef struct {
+	// Repository is the image name, including any registry, such as
+	// "quay.io/coreos/etcd".
+	Repository string
+	// Tag is the image tag, or "" if there is none.
+	Tag string
+	// Digest is the image digest, such as "sha256:...", or "" if there is none.
+	Digest string
+	// SourceTemplate is the name of the rendered template that refers to the
+	// image.
+	SourceTemplate string
+	// Unresolved is set if the reference still contains template syntax, or
+	// a value that was missing at render time, so it is not a usable image.
+	Unresolved bool
+}
+
+// String returns the reference as it would appear in a manifest.
+func (r ImageRef) String() string {
+	s := r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// ListImages returns the container images used in a set of rendered
+// templates, such as the output of the template engine's Render.
+//
+// Each template is parsed as a stream of YAML documents, and the image of
+// every container in a containers or initContainers list is returned. These
+// lists are found at any depth, so the pod templates of Deployments, Jobs,
+// and other controllers are included. Templates are visited in order of
+// name. NOTES.txt and partials are skipped. An error is returned if a
+// template is not valid YAML.
+func ListImages(rendered map[string]string) ([]ImageRef, error) {
+	names := make([]string, 0, len(rendered))
+	for n := range rendered {
+		base := path.Base(n)
+		if base == NotesName || strings.HasPrefix(base, "_") {
+			continue
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var refs []ImageRef
+	for _, n := range names {
+		for _, doc := range docSeparator.Split(rendered[n], -1) {
+			if isEmptyDocument(doc) {
+				continue
+			}
+			var obj interface{}
+			if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+				return refs, fmt.Errorf("cannot parse %s: %s", n, err)
+			}
+			for _, image := range findImages(obj) {
+				ref := parseImageRef(image)
+				ref.SourceTemplate = n
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// findImages returns the image of each container found in v.
+func findImages(v interface{}) []string {
+	var images []string
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "containers" || k == "initContainers" {
+				if list, ok := v[k].([]interface{}); ok {
+					for _, c := range list {
+						if c, ok := c.(map[string]interface{}); ok {
+							if image, ok := c["image"].(string); ok {
+								images = append(images, image)
+							}
+						}
+					}
+					continue
+				}
+			}
+			images = append(images, findImages(v[k])...)
+		}
+	case []interface{}:
+		for _, e := range v {
+			images = append(images, findImages(e)...)
+		}
+	}
+	return images
+}
+
+// parseImageRef splits an image reference into its parts.
+func parseImageRef(image string) ImageRef {
+	ref := ImageRef{}
+	image = strings.TrimSpace(image)
+	if i := strings.Index(image, "@"); i >= 0 {
+		ref.Digest = image[i+1:]
+		image = image[:i]
+	}
+	// A colon before the last slash separates a registry host from its port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		ref.Tag = image[i+1:]
+		image = image[:i]
+		if ref.Tag == "" {
+			ref.Unresolved = true
+		}
+	}
+	ref.Repository = image
+	if image == "" || strings.HasSuffix(image, "/") ||
+		strings.Contains(image, "{{") || strings.Contains(image, "<no value>") ||
+		strings.Contains(ref.Tag, "<no value>") || strings.Contains(ref.Tag, "{{") {
+		ref.Unresolved = true
+	}
+	return ref
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListImages(t *testing.T) {
+	rendered := map[string]string{
+		"ahab/templates/deployment.yaml": `apiVersion: extensions/v1beta1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox
+      containers:
+        - name: web
+          image: quay.io/ahab/web:1.2.3
+        - name: proxy
+          image: localhost:5000/proxy@sha256:abc123
+---
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+    - name: sidecar
+      image: "sidecar:<no value>"
+`,
+		"ahab/templates/service.yaml": "apiVersion: v1\nkind: Service\n",
+		"ahab/templates/NOTES.txt":    "Thar she blows: {{ not yaml",
+	}
+
+	refs, err := ListImages(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "ahab/templates/deployment.yaml"
+	// Keys are visited in order, so containers come before initContainers.
+	expect := []ImageRef{
+		{Repository: "quay.io/ahab/web", Tag: "1.2.3", SourceTemplate: src},
+		{Repository: "localhost:5000/proxy", Digest: "sha256:abc123", SourceTemplate: src},
+		{Repository: "busybox", SourceTemplate: src},
+		{Repository: "sidecar", Tag: "<no value>", SourceTemplate: src, Unresolved: true},
+	}
+	if !reflect.DeepEqual(refs, expect) {
+		t.Errorf("Expected %v, got %v", expect, refs)
+	}
+	if s := refs[1].String(); s != "localhost:5000/proxy@sha256:abc123" {
+		t.Errorf("Unexpected string form %q", s)
+	}
+
+	if _, err := ListImages(map[string]string{"ahab/templates/bad.yaml": "a: b: c"}); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
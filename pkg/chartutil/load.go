@@ -18,17 +18,29 @@ package chartutil
 
 import (
 	"archive/tar"
+	"archive/zip"
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/any"
+	"golang.org/x/net/context"
 
 	"k8s.io/helm/pkg/ignore"
 	"k8s.io/helm/pkg/proto/hapi/chart"
@@ -42,14 +54,173 @@ import (
 // If a .helmignore file is present, the directory loader will skip loading any files
 // matching it. But .helmignore is not evaluated when reading out of an archive.
 func Load(name string) (*chart.Chart, error) {
+	return LoadWithOptions(name)
+}
+
+// LoadWithOptions is like Load, but allows the caller to supply LoadOptions.
+func LoadWithOptions(name string, opts ...LoadOption) (*chart.Chart, error) {
 	fi, err := os.Stat(name)
 	if err != nil {
 		return nil, err
 	}
+	o := newLoadOptions(opts)
 	if fi.IsDir() {
-		return LoadDir(name)
+		return o.instrument("dir", func() (*chart.Chart, error) { return loadDir(name, o) })
+	}
+	return o.instrument("archive", func() (*chart.Chart, error) { return loadFile(name, o) })
+}
+
+// LoadChart loads a chart from a URL, an archive file, or a directory.
+//
+// If name starts with http:// or https://, it is fetched with LoadURL. If it
+// has a .tgz or .tar.gz extension and exists, it is loaded with LoadFile.
+// Otherwise, it is loaded with LoadDir.
+func LoadChart(ctx context.Context, name string, opts ...LoadOption) (*chart.Chart, error) {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return LoadURL(ctx, name, opts...)
+	}
+	if strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".tar.gz") {
+		if _, err := os.Stat(name); err == nil {
+			return LoadFile(name, opts...)
+		}
+	}
+	return LoadDir(name, opts...)
+}
+
+// LoadURL fetches a chart archive over HTTP(S) and loads it.
+//
+// The request is canceled if ctx is done before the archive has been read.
+func LoadURL(ctx context.Context, href string, opts ...LoadOption) (*chart.Chart, error) {
+	req, err := http.NewRequest("GET", href, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
 	}
-	return LoadFile(name)
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to fetch %s : %s", href, resp.Status)
+	}
+	return LoadArchive(resp.Body, opts...)
+}
+
+// gzipMagic is the header that starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// zstdMagic is the header of a zstd frame.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// archiveFormat is a compression format registered with RegisterArchiveFormat.
+type archiveFormat struct {
+	name   string
+	magic  []byte
+	opener func(io.Reader) (io.Reader, error)
+}
+
+var (
+	archiveFormatsMu sync.RWMutex
+	archiveFormats   = []archiveFormat{{"gzip", gzipMagic, openGzip}}
+)
+
+// openGzip is the opener for the built-in gzip format.
+func openGzip(in io.Reader) (io.Reader, error) {
+	return gzip.NewReader(in)
+}
+
+// RegisterArchiveFormat adds a compression format for chart archives. The
+// name is used in the error for archives in a format that is not registered.
+//
+// Archives whose first bytes match magic are decompressed with opener before
+// being read as a tar archive. If the reader returned by opener is also an
+// io.Closer, it is closed once the archive has been read. Formats registered
+// later take precedence, so a built-in format such as gzip can be replaced.
+// Gzip is registered by default, and zstd is when built with Go 1.19 or later.
+//
+// RegisterArchiveFormat is typically called from an init function.
+func RegisterArchiveFormat(name string, magic []byte, opener func(io.Reader) (io.Reader, error)) {
+	archiveFormatsMu.Lock()
+	defer archiveFormatsMu.Unlock()
+	archiveFormats = append(archiveFormats, archiveFormat{name, append([]byte(nil), magic...), opener})
+}
+
+// archiveFormatNames returns the names of the registered formats, in the
+// order they were first registered.
+func archiveFormatNames() []string {
+	archiveFormatsMu.RLock()
+	defer archiveFormatsMu.RUnlock()
+	var names []string
+	seen := map[string]bool{}
+	for _, f := range archiveFormats {
+		if !seen[f.name] {
+			seen[f.name] = true
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// lookupArchiveFormat returns the opener of the most recently registered
+// format whose magic matches header, and nil if there is none.
+func lookupArchiveFormat(header []byte) func(io.Reader) (io.Reader, error) {
+	archiveFormatsMu.RLock()
+	defer archiveFormatsMu.RUnlock()
+	for i := len(archiveFormats) - 1; i >= 0; i-- {
+		if bytes.HasPrefix(header, archiveFormats[i].magic) {
+			return archiveFormats[i].opener
+		}
+	}
+	return nil
+}
+
+// magicLen returns the number of bytes needed to tell apart the registered
+// and known unsupported formats.
+func magicLen() int {
+	archiveFormatsMu.RLock()
+	defer archiveFormatsMu.RUnlock()
+	n := 0
+	for _, f := range archiveFormats {
+		if len(f.magic) > n {
+			n = len(f.magic)
+		}
+	}
+	for _, u := range unsupportedMagic {
+		if len(u.magic) > n {
+			n = len(u.magic)
+		}
+	}
+	return n
+}
+
+// unsupportedMagic maps the headers of common compression formats that are
+// not registered to their names.
+var unsupportedMagic = []struct {
+	format string
+	magic  []byte
+}{
+	{"bzip2", []byte{0x42, 0x5a, 0x68}},
+	{"xz", []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}},
+}
+
+// UnsupportedCompressionError indicates that an archive was compressed with
+// a known format that is not registered with RegisterArchiveFormat.
+type UnsupportedCompressionError string
+
+func (e UnsupportedCompressionError) Error() string {
+	return string(e)
+}
+
+// detectCompression returns an UnsupportedCompressionError, listing the
+// registered formats, if header matches a known compression format, or nil if
+// it does not.
+func detectCompression(header []byte) error {
+	for _, u := range unsupportedMagic {
+		if bytes.HasPrefix(header, u.magic) {
+			return UnsupportedCompressionError(fmt.Sprintf("%s detected; supported formats are %s", u.format, strings.Join(archiveFormatNames(), ", ")))
+		}
+	}
+	return nil
 }
 
 // afile represents an archive file buffered for later processing.
@@ -58,17 +229,272 @@ type afile struct {
 	data []byte
 }
 
+// sizeLimiter tracks the number of bytes loaded against LoadOptions.MaxSize.
+type sizeLimiter struct {
+	max, total int64
+}
+
+// add records n more bytes, returning an error if the limit is exceeded.
+func (l *sizeLimiter) add(n int64) error {
+	l.total += n
+	if l.max > 0 && l.total > l.max {
+		return fmt.Errorf("chart exceeds maximum size of %d bytes", l.max)
+	}
+	return nil
+}
+
 // LoadArchive loads from a reader containing a compressed tar archive.
-func LoadArchive(in io.Reader) (*chart.Chart, error) {
-	unzipped, err := gzip.NewReader(in)
+//
+// Archives are normally compressed with gzip. The format is detected from the
+// stream's header; see RegisterArchiveFormat for the formats that are
+// accepted.
+func LoadArchive(in io.Reader, opts ...LoadOption) (*chart.Chart, error) {
+	o := newLoadOptions(opts)
+	return o.instrument("archive", func() (*chart.Chart, error) { return loadArchive(in, o) })
+}
+
+// LoadArchiveProgress loads from a reader containing a compressed tar archive,
+// calling onFile for each entry in the archive.
+//
+// onFile is called with the entry's name and the size recorded in its header
+// before the entry's contents are read, so it can be used to report progress
+// on large archives. It is not called for the entries of packaged subcharts.
+func LoadArchiveProgress(in io.Reader, onFile func(name string, size int64)) (*chart.Chart, error) {
+	o := newLoadOptions(nil)
+	o.onFile = onFile
+	return loadArchive(in, o)
+}
+
+// LoadArchiveRaw loads a chart like LoadArchive, and also returns the archive
+// exactly as it was read.
+//
+// This lets a caller verify the archive's provenance without reading it a
+// second time. The archive is copied as it is read, so the whole archive is
+// held in memory alongside the chart. The bytes are returned beside the chart
+// rather than in it, so that they are not sent to Tiller or stored in release
+// records.
+func LoadArchiveRaw(in io.Reader, opts ...LoadOption) (*chart.Chart, []byte, error) {
+	o := newLoadOptions(opts)
+	raw := &bytes.Buffer{}
+	tee := io.TeeReader(in, raw)
+	c, err := o.instrument("archive", func() (*chart.Chart, error) { return loadArchive(tee, o) })
+	if err != nil {
+		return c, nil, err
+	}
+	// Decompression can stop short of the end of the stream, such as
+	// before the gzip trailer, so copy whatever is left.
+	if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+		return c, nil, err
+	}
+	return c, raw.Bytes(), nil
+}
+
+var (
+	rawArchivesMu sync.Mutex
+	// rawArchives holds the archives kept by WithRawArchive. Charts are
+	// keyed by address, so that the table does not keep them from being
+	// garbage collected; a finalizer removes each entry.
+	rawArchives = map[uintptr][]byte{}
+)
+
+// RawArchive returns the archive that c was loaded from, exactly as it was
+// read, or nil if c was not loaded from an archive with WithRawArchive.
+func RawArchive(c *chart.Chart) []byte {
+	if c == nil {
+		return nil
+	}
+	rawArchivesMu.Lock()
+	defer rawArchivesMu.Unlock()
+	return rawArchives[reflect.ValueOf(c).Pointer()]
+}
+
+// setRawArchive records raw as the archive c was loaded from.
+func setRawArchive(c *chart.Chart, raw []byte) {
+	key := reflect.ValueOf(c).Pointer()
+	rawArchivesMu.Lock()
+	rawArchives[key] = raw
+	rawArchivesMu.Unlock()
+	runtime.SetFinalizer(c, func(*chart.Chart) {
+		rawArchivesMu.Lock()
+		delete(rawArchives, key)
+		rawArchivesMu.Unlock()
+	})
+}
+
+func loadArchive(in io.Reader, o *LoadOptions) (*chart.Chart, error) {
+	if !o.RawArchive {
+		return loadCompressed(in, o)
+	}
+	raw := &bytes.Buffer{}
+	tee := io.TeeReader(in, raw)
+	c, err := loadCompressed(tee, o)
+	if err != nil {
+		return c, err
+	}
+	// Decompression can stop short of the end of the stream, such as
+	// before the gzip trailer, so copy whatever is left.
+	if _, err := io.Copy(ioutil.Discard, tee); err != nil {
+		return c, err
+	}
+	setRawArchive(c, raw.Bytes())
+	return c, nil
+}
+
+// loadCompressed loads a chart from a reader containing a tar archive that
+// has been compressed with one of the registered formats.
+func loadCompressed(in io.Reader, o *LoadOptions) (*chart.Chart, error) {
+	r, err := decompress(in)
 	if err != nil {
 		return &chart.Chart{}, err
 	}
-	defer unzipped.Close()
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	return loadTar(r, o)
+}
+
+// decompress returns a reader of the tar archive in in, using the registered
+// format that matches its header. If the returned reader is an io.Closer, the
+// caller must close it.
+func decompress(in io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(in)
+	// Errors here are deliberately ignored; the opener will report them below.
+	header, _ := br.Peek(magicLen())
+	opener := lookupArchiveFormat(header)
+	if opener == nil {
+		if err := detectCompression(header); err != nil {
+			return nil, err
+		}
+		// Let gzip report what is wrong with anything unrecognized.
+		opener = openGzip
+	}
+	return opener(br)
+}
+
+// Format is the format of a chart archive read by LoadReader.
+type Format int
+
+const (
+	// FormatAuto detects the format from the start of the stream.
+	FormatAuto Format = iota
+	// FormatGzip is a gzipped tar archive, as made by 'helm package'.
+	FormatGzip
+	// FormatTar is an uncompressed tar archive.
+	FormatTar
+	// FormatZip is a zip archive.
+	FormatZip
+)
+
+// zipMagic is the header of a zip archive's first local file.
+var zipMagic = []byte("PK\x03\x04")
+
+// tarMagic is the format name in a POSIX or GNU tar header, and tarMagicOffset
+// is its offset from the start of the header.
+var tarMagic = []byte("ustar")
 
+const tarMagicOffset = 257
+
+// LoadReader loads a chart archive of the given format from a stream, such as
+// standard input.
+//
+// With FormatAuto, zip archives and uncompressed tar archives are recognized
+// by their headers, and anything else is read as LoadArchive would, so any
+// registered compression format is accepted. A zip archive must be read
+// into memory in full before it can be loaded, but MaxSize is checked
+// against the sizes in its directory first.
+func LoadReader(in io.Reader, format Format, opts ...LoadOption) (*chart.Chart, error) {
+	o := newLoadOptions(opts)
+	return o.instrument("archive", func() (*chart.Chart, error) { return loadReader(in, format, o) })
+}
+
+func loadReader(in io.Reader, format Format, o *LoadOptions) (*chart.Chart, error) {
+	switch format {
+	case FormatAuto:
+		br := bufio.NewReader(in)
+		// Errors here are deliberately ignored; short streams are reported below.
+		header, _ := br.Peek(tarMagicOffset + len(tarMagic))
+		if bytes.HasPrefix(header, zipMagic) {
+			return loadZip(br, o)
+		}
+		if len(header) == tarMagicOffset+len(tarMagic) && bytes.Equal(header[tarMagicOffset:], tarMagic) {
+			return loadTar(br, o)
+		}
+		return loadArchive(br, o)
+	case FormatGzip:
+		r, err := gzip.NewReader(in)
+		if err != nil {
+			return &chart.Chart{}, err
+		}
+		defer r.Close()
+		return loadTar(r, o)
+	case FormatTar:
+		return loadTar(in, o)
+	case FormatZip:
+		return loadZip(in, o)
+	}
+	return &chart.Chart{}, fmt.Errorf("unknown archive format %d", format)
+}
+
+// loadZip loads a chart from a reader containing a zip archive.
+//
+// The entries are copied into a tar archive in memory, so that zip archives
+// are loaded exactly as tar archives are.
+func loadZip(in io.Reader, o *LoadOptions) (*chart.Chart, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return &chart.Chart{}, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return &chart.Chart{}, err
+	}
+
+	limit := &sizeLimiter{max: o.MaxSize}
+	for _, f := range zr.File {
+		if err := limit.add(int64(f.UncompressedSize64)); err != nil {
+			return &chart.Chart{}, err
+		}
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return &chart.Chart{}, err
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return &chart.Chart{}, fmt.Errorf("cannot read %s: %s", f.Name, err)
+		}
+		if err := writeToTar(tw, f.Name, b); err != nil {
+			return &chart.Chart{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return &chart.Chart{}, err
+	}
+	return loadTar(&buf, o)
+}
+
+// loadTar loads a chart from a reader containing an uncompressed tar archive.
+func loadTar(in io.Reader, o *LoadOptions) (*chart.Chart, error) {
 	files := []*afile{}
-	tr := tar.NewReader(unzipped)
+	flat := false
+	limit := &sizeLimiter{max: o.MaxSize}
+	if o.Timeout > 0 && o.deadline.IsZero() {
+		o.deadline = time.Now().Add(o.Timeout)
+	}
+	tr := tar.NewReader(in)
 	for {
+		if !o.deadline.IsZero() && time.Now().After(o.deadline) {
+			return &chart.Chart{}, context.DeadlineExceeded
+		}
 		b := bytes.NewBuffer(nil)
 		hd, err := tr.Next()
 		if err == io.EOF {
@@ -84,18 +510,64 @@ func LoadArchive(in io.Reader) (*chart.Chart, error) {
 			continue
 		}
 
-		parts := strings.Split(hd.Name, "/")
-		n := strings.Join(parts[1:], "/")
+		// archive/tar applies GNU long name and PAX extended header records to
+		// the entry that follows them, but returns PAX global headers, such as
+		// the one git archive writes, as entries of their own.
+		switch hd.Typeflag {
+		case tar.TypeXGlobalHeader, tar.TypeXHeader, tar.TypeGNULongName, tar.TypeGNULongLink:
+			continue
+		case tar.TypeSymlink, tar.TypeLink:
+			if o.RejectLinks {
+				return &chart.Chart{}, fmt.Errorf("archive entry %s is a link to %s", hd.Name, hd.Linkname)
+			}
+			log.Printf("warning: skipping archive entry %s, which is a link to %s", hd.Name, hd.Linkname)
+			continue
+		}
+
+		// Some tools record the chart directory as a file, omit the trailing
+		// separator on directories, or write absolute names.
+		name := hd.Name
+		if o.SanitizeNames {
+			name = sanitizeName(name)
+		}
+		name = strings.TrimLeft(name, "/")
+		if name == "" || strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		if strings.SplitN(name, "/", 2)[0] == ChartfileName {
+			if !o.AllowFlatArchive {
+				return nil, errors.New("chart yaml not in base directory")
+			}
+			flat = true
+		}
 
-		if parts[0] == "Chart.yaml" {
-			return nil, errors.New("chart yaml not in base directory")
+		if o.onFile != nil {
+			o.onFile(hd.Name, hd.Size)
 		}
 
-		if _, err := io.Copy(b, tr); err != nil {
+		start := time.Now()
+		var r io.Reader = tr
+		if o.MaxSize > 0 {
+			// Read at most one byte past the limit so that we can tell when
+			// it has been exceeded without buffering the whole entry.
+			r = io.LimitReader(tr, o.MaxSize-limit.total+1)
+		}
+		written, err := io.Copy(b, r)
+		if err != nil {
+			return &chart.Chart{}, err
+		}
+		if err := limit.add(written); err != nil {
 			return &chart.Chart{}, err
 		}
+		if o.Profile != nil {
+			o.Profile.record(hd.Name, start)
+		}
+		if o.EntryOrder != nil {
+			*o.EntryOrder = append(*o.EntryOrder, name)
+		}
 
-		files = append(files, &afile{name: n, data: b.Bytes()})
+		files = append(files, &afile{name: name, data: b.Bytes()})
 		b.Reset()
 	}
 
@@ -103,28 +575,237 @@ func LoadArchive(in io.Reader) (*chart.Chart, error) {
 		return nil, errors.New("no files in chart archive")
 	}
 
-	return loadFiles(files)
+	// Strip the top-level directory, unless the archive doesn't have one.
+	// Entries with no directory are the top-level directory itself.
+	if !flat {
+		stripped := files[:0]
+		for _, f := range files {
+			parts := strings.Split(f.name, "/")
+			if len(parts) < 2 {
+				continue
+			}
+			f.name = strings.Join(parts[1:], "/")
+			stripped = append(stripped, f)
+		}
+		files = stripped
+	}
+	if o.Metrics != nil {
+		o.Metrics.files.Add(float64(len(files)))
+	}
+
+	// Packaged subcharts are read from memory, so don't report their entries.
+	sub := *o
+	sub.onFile = nil
+	sub.Profile = nil
+	sub.EntryOrder = nil
+	return loadFiles(files, &sub)
+}
+
+// LoadMultipart loads a chart from a part of a multipart/form-data upload.
+//
+// The format is determined by the extension of the part's declared file name.
+// Currently, only gzipped archives (.tgz and .tar.gz) are accepted.
+func LoadMultipart(part *multipart.Part, opts ...LoadOption) (*chart.Chart, error) {
+	name := part.FileName()
+	if !strings.HasSuffix(name, ".tgz") && !strings.HasSuffix(name, ".tar.gz") {
+		return nil, fmt.Errorf("unsupported chart file type %q: expected a .tgz archive", name)
+	}
+	return LoadArchive(part, opts...)
 }
 
-func loadFiles(files []*afile) (*chart.Chart, error) {
+// LoadTarMetadata reads the Chart.yaml out of an uncompressed tar archive.
+//
+// Only the chart's metadata is parsed; all other entries are skipped. If the
+// stream is gzip-compressed, an error is returned, and LoadArchive should be
+// used instead.
+func LoadTarMetadata(in io.Reader) (*chart.Metadata, error) {
+	r := bufio.NewReader(in)
+	if magic, err := r.Peek(2); err == nil && bytes.Equal(magic, gzipMagic) {
+		return nil, errors.New("archive is gzip-compressed: use LoadArchive to read compressed charts")
+	}
+	return readTarMetadata(tar.NewReader(r))
+}
+
+// LoadMetadata reads only the Chart.yaml of the chart in dir.
+//
+// Unlike LoadDir, this does not walk the chart directory, which makes it
+// suitable for indexing large numbers of charts.
+func LoadMetadata(dir string) (*chart.Metadata, error) {
+	return LoadChartfile(filepath.Join(dir, ChartfileName))
+}
+
+// LoadMetadataFromArchive reads only the Chart.yaml from a compressed tar archive.
+//
+// Reading stops as soon as Chart.yaml has been found; the rest of the stream
+// is not decompressed.
+func LoadMetadataFromArchive(in io.Reader) (*chart.Metadata, error) {
+	unzipped, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	defer unzipped.Close()
+	return readTarMetadata(tar.NewReader(unzipped))
+}
+
+// LoadValidationBundle reads only the values.yaml and values.schema.json of
+// a chart, for validating values against the chart's schema.
+//
+// name is a chart directory or a compressed chart archive. Nothing else in
+// the chart is read: templates and dependencies are not loaded, and reading
+// an archive stops as soon as both files have been found. A file the chart
+// does not have is returned as nil. A leading byte order mark is removed
+// from values.yaml, as it is by Load.
+func LoadValidationBundle(name string) (values []byte, schema []byte, err error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fi.IsDir() {
+		values, err = readOptionalFile(filepath.Join(name, ValuesfileName))
+		if err != nil {
+			return nil, nil, err
+		}
+		schema, err = readOptionalFile(filepath.Join(name, SchemafileName))
+		if err != nil {
+			return nil, nil, err
+		}
+		return bytes.TrimPrefix(values, utf8BOM), schema, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	r, err := decompress(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	tr := tar.NewReader(r)
+	for values == nil || schema == nil {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		parts := strings.Split(strings.TrimLeft(hd.Name, "/"), "/")
+		if len(parts) != 2 || (parts[1] != ValuesfileName && parts[1] != SchemafileName) {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, nil, err
+		}
+		if parts[1] == ValuesfileName {
+			values = bytes.TrimPrefix(data, utf8BOM)
+		} else {
+			schema = data
+		}
+	}
+	return values, schema, nil
+}
+
+// readOptionalFile reads a file, returning nil if it does not exist.
+func readOptionalFile(name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// readTarMetadata reads entries from tr until it finds the top-level Chart.yaml.
+func readTarMetadata(tr *tar.Reader) (*chart.Metadata, error) {
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parts := strings.Split(hd.Name, "/")
+		if len(parts) != 2 || parts[1] != ChartfileName {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		return UnmarshalChartfile(data)
+	}
+	return nil, errors.New("chart metadata (Chart.yaml) missing")
+}
+
+func loadFiles(files []*afile, o *LoadOptions) (*chart.Chart, error) {
 	c := &chart.Chart{}
 	subcharts := map[string][]*afile{}
+	provs := map[string]*afile{}
 
 	for _, f := range files {
+		if err := checkExtension(f.name, o.AllowedExtensions); err != nil {
+			return c, err
+		}
+		// A byte order mark is not part of the text, but YAML and templates
+		// would treat it as content.
+		if f.name == ChartfileName || f.name == ValuesfileName || strings.HasPrefix(f.name, "templates/") {
+			f.data = bytes.TrimPrefix(f.data, utf8BOM)
+		}
 		if f.name == "Chart.yaml" {
 			m, err := UnmarshalChartfile(f.data)
 			if err != nil {
 				return c, err
 			}
+			if o.MetadataTransform != nil {
+				if m = o.MetadataTransform(m); m == nil {
+					return c, errors.New("metadata transform returned no metadata")
+				}
+			}
 			c.Metadata = m
 		} else if f.name == "values.toml" {
 			return c, errors.New("values.toml is illegal as of 2.0.0-alpha.2")
 		} else if f.name == "values.yaml" {
+			if o.ExpandEnv {
+				expanded, err := expandEnv(f.data, o.Env, o.ErrorOnUnsetEnv)
+				if err != nil {
+					return c, fmt.Errorf("cannot expand values.yaml: %s", err)
+				}
+				f.data = expanded
+			}
+			if o.StrictValues {
+				if _, err := ReadValues(f.data); err != nil {
+					return c, fmt.Errorf("cannot parse values.yaml: %s", err)
+				}
+			}
 			c.Values = &chart.Config{Raw: string(f.data)}
 		} else if strings.HasPrefix(f.name, "templates/") {
+			if o.TemplateFilter != nil && !o.TemplateFilter(f.name) {
+				continue
+			}
 			c.Templates = append(c.Templates, &chart.Template{Name: f.name, Data: f.data})
 		} else if strings.HasPrefix(f.name, "charts/") {
+			if o.SkipDependencies {
+				continue
+			}
+			if o.FlatLoad {
+				c.Files = append(c.Files, &any.Any{TypeUrl: f.name, Value: f.data})
+				continue
+			}
 			if filepath.Ext(f.name) == ".prov" {
+				if o.SubchartProvenance {
+					provs[strings.TrimSuffix(strings.TrimPrefix(f.name, "charts/"), ".prov")] = f
+					continue
+				}
 				c.Files = append(c.Files, &any.Any{TypeUrl: f.name, Value: f.data})
 				continue
 			}
@@ -137,28 +818,67 @@ func loadFiles(files []*afile) (*chart.Chart, error) {
 			scname := parts[0]
 			subcharts[scname] = append(subcharts[scname], &afile{name: cname, data: f.data})
 		} else {
+			if o.StrictMode && !knownFiles[f.name] {
+				return c, UnexpectedFileError(f.name)
+			}
 			c.Files = append(c.Files, &any.Any{TypeUrl: f.name, Value: f.data})
 		}
 	}
 
+	// Provenance files without a packaged subchart to go with stay here.
+	unmatched := []string{}
+	for n := range provs {
+		if _, ok := subcharts[n]; !ok || archiveExt(n) == "" {
+			unmatched = append(unmatched, n)
+		}
+	}
+	sort.Strings(unmatched)
+	for _, n := range unmatched {
+		c.Files = append(c.Files, &any.Any{TypeUrl: provs[n].name, Value: provs[n].data})
+		delete(provs, n)
+	}
+
 	// Ensure that we got a Chart.yaml file
 	if c.Metadata == nil || c.Metadata.Name == "" {
 		return c, errors.New("chart metadata (Chart.yaml) missing")
 	}
 
+	if o.MaxTemplates > 0 && len(c.Templates) > o.MaxTemplates {
+		return c, fmt.Errorf("chart %s has %d templates, more than the maximum of %d", c.Metadata.Name, len(c.Templates), o.MaxTemplates)
+	}
+	if o.MaxFiles > 0 && len(c.Files) > o.MaxFiles {
+		return c, fmt.Errorf("chart %s has %d files, more than the maximum of %d", c.Metadata.Name, len(c.Files), o.MaxFiles)
+	}
+
+	checkAPIVersionFields(c)
+
+	if err := checkCaseCollisions(c.Templates, o.RejectCaseCollisions); err != nil {
+		return c, err
+	}
+
 	for n, files := range subcharts {
 		var sc *chart.Chart
 		var err error
 		if strings.IndexAny(n, "_.") == 0 {
 			continue
-		} else if filepath.Ext(n) == ".tgz" {
+		} else if ext := archiveExt(n); ext != "" {
 			file := files[0]
 			if file.name != n {
 				return c, fmt.Errorf("error unpacking tar in %s: expected %s, got %s", c.Metadata.Name, n, file.name)
 			}
 			// Untar the chart and add to c.Dependencies
 			b := bytes.NewBuffer(file.data)
-			sc, err = LoadArchive(b)
+			if ext == ".tar" {
+				sc, err = loadTar(b, o)
+			} else {
+				sc, err = loadArchive(b, o)
+			}
+			if err != nil {
+				if err == io.ErrUnexpectedEOF || err == gzip.ErrHeader || err == tar.ErrHeader {
+					err = fmt.Errorf("corrupt tar archive: %s", err)
+				}
+				return c, fmt.Errorf("failed to load dependency '%s' (%s): %s", strings.TrimSuffix(n, ext), ChartsDir+"/"+n, err)
+			}
 		} else {
 			// We have to trim the prefix off of every file, and ignore any file
 			// that is in charts/, but isn't actually a chart.
@@ -171,12 +891,18 @@ func loadFiles(files []*afile) (*chart.Chart, error) {
 				f.name = parts[1]
 				buff = append(buff, f)
 			}
-			sc, err = loadFiles(buff)
+			sc, err = loadFiles(buff, o)
+			if err == nil {
+				err = checkSubchartName(n, sc.Metadata.Name, o.RejectSubchartNameMismatch)
+			}
 		}
 
 		if err != nil {
 			return c, fmt.Errorf("error unpacking %s in %s: %s", n, c.Metadata.Name, err)
 		}
+		if p, ok := provs[n]; ok {
+			sc.Files = append(sc.Files, &any.Any{TypeUrl: n + ".prov", Value: p.data})
+		}
 
 		c.Dependencies = append(c.Dependencies, sc)
 	}
@@ -184,8 +910,144 @@ func loadFiles(files []*afile) (*chart.Chart, error) {
 	return c, nil
 }
 
+// UnexpectedFileError indicates that a chart loaded with WithStrictMode
+// contains a file that Helm does not use. Its value is the file's name.
+type UnexpectedFileError string
+
+func (e UnexpectedFileError) Error() string {
+	return "unexpected file in chart: " + string(e)
+}
+
+// unsafeNameRunes matches the characters that WithSanitizeNames removes.
+var unsafeNameRunes = regexp.MustCompile(`[^a-zA-Z0-9._/-]+`)
+
+// sanitizeName converts backslashes in name to slashes and removes unsafe
+// characters, logging a warning if anything was changed.
+func sanitizeName(name string) string {
+	clean := unsafeNameRunes.ReplaceAllString(strings.Replace(name, "\\", "/", -1), "")
+	if clean != name {
+		log.Printf("warning: archive entry %q renamed to %q", name, clean)
+	}
+	return clean
+}
+
+// fileReadError is an error reading a file in a chart directory. The cause is
+// kept so that LoadDirWithRetry can tell whether it is transient.
+type fileReadError struct {
+	name string
+	err  error
+}
+
+func (e *fileReadError) Error() string {
+	return fmt.Sprintf("error reading %s: %s", e.name, e.err)
+}
+
+// utf8BOM is the UTF-8 encoding of the byte order mark, which some editors
+// write at the start of text files.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// knownFiles are the files outside templates/ and charts/ that Helm uses.
+var knownFiles = map[string]bool{
+	IgnorefileName:   true,
+	SchemafileName:   true,
+	requirementsName: true,
+	lockfileName:     true,
+}
+
+// archiveExts are the extensions that mark a file in charts/ as a packaged chart.
+//
+// Longer extensions must come before any they end with.
+var archiveExts = []string{".tar.gz", ".tgz", ".tar"}
+
+// archiveExt returns the packaged chart extension of name, or "" if it has none.
+func archiveExt(name string) string {
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// checkAPIVersionFields logs a warning when a chart uses Chart.yaml fields or
+// files that do not belong to its apiVersion.
+func checkAPIVersionFields(c *chart.Chart) {
+	name := c.Metadata.Name
+	switch v := APIVersion(c); v {
+	case ApiVersionV1:
+		if len(c.Metadata.Dependencies) > 0 {
+			log.Printf("warning: chart %s has apiVersion %s, so Chart.yaml dependencies are ignored; use %s", name, v, requirementsName)
+		}
+		if c.Metadata.Type != "" {
+			log.Printf("warning: chart %s has apiVersion %s, which does not support the type field", name, v)
+		}
+	case ApiVersionV2:
+		for _, f := range c.Files {
+			if f.TypeUrl == requirementsName {
+				log.Printf("warning: chart %s has apiVersion %s, so %s is ignored; list dependencies in %s", name, v, requirementsName, ChartfileName)
+			}
+		}
+	default:
+		log.Printf("warning: chart %s has unknown apiVersion %q", name, v)
+	}
+}
+
+// checkExtension returns an error if name is a file that may be restricted by
+// AllowedExtensions and its extension is not in allowed.
+func checkExtension(name string, allowed []string) error {
+	if len(allowed) == 0 || name == ChartfileName || name == ValuesfileName ||
+		strings.HasPrefix(name, TemplatesDir+"/") || strings.HasPrefix(name, ChartsDir+"/") {
+		return nil
+	}
+	ext := filepath.Ext(name)
+	for _, a := range allowed {
+		if strings.EqualFold(ext, a) {
+			return nil
+		}
+	}
+	return fmt.Errorf("file %s has a disallowed extension %q", name, ext)
+}
+
+// checkSubchartName checks that the directory a subchart was loaded from is
+// named after the subchart. If reject is false, a mismatch is only logged.
+func checkSubchartName(dir, name string, reject bool) error {
+	if dir == name {
+		return nil
+	}
+	if reject {
+		return fmt.Errorf("subchart directory %s/%s does not match chart name %q", ChartsDir, dir, name)
+	}
+	log.Printf("warning: subchart directory %s/%s does not match chart name %q", ChartsDir, dir, name)
+	return nil
+}
+
+// checkCaseCollisions looks for templates whose names differ only by case.
+//
+// If reject is false, collisions are logged rather than returned.
+func checkCaseCollisions(templates []*chart.Template, reject bool) error {
+	seen := make(map[string]string, len(templates))
+	for _, t := range templates {
+		lower := strings.ToLower(t.Name)
+		prev, ok := seen[lower]
+		if !ok {
+			seen[lower] = t.Name
+			continue
+		}
+		if reject {
+			return fmt.Errorf("template names %s and %s differ only by case", prev, t.Name)
+		}
+		log.Printf("warning: template names %s and %s differ only by case", prev, t.Name)
+	}
+	return nil
+}
+
 // LoadFile loads from an archive file.
-func LoadFile(name string) (*chart.Chart, error) {
+func LoadFile(name string, opts ...LoadOption) (*chart.Chart, error) {
+	o := newLoadOptions(opts)
+	return o.instrument("archive", func() (*chart.Chart, error) { return loadFile(name, o) })
+}
+
+func loadFile(name string, o *LoadOptions) (*chart.Chart, error) {
 	if fi, err := os.Stat(name); err != nil {
 		return nil, err
 	} else if fi.IsDir() {
@@ -198,13 +1060,18 @@ func LoadFile(name string) (*chart.Chart, error) {
 	}
 	defer raw.Close()
 
-	return LoadArchive(raw)
+	return loadArchive(raw, o)
 }
 
 // LoadDir loads from a directory.
 //
 // This loads charts only from directories.
-func LoadDir(dir string) (*chart.Chart, error) {
+func LoadDir(dir string, opts ...LoadOption) (*chart.Chart, error) {
+	o := newLoadOptions(opts)
+	return o.instrument("dir", func() (*chart.Chart, error) { return loadDir(dir, o) })
+}
+
+func loadDir(dir string, o *LoadOptions) (*chart.Chart, error) {
 	topdir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, err
@@ -213,6 +1080,12 @@ func LoadDir(dir string) (*chart.Chart, error) {
 	// Just used for errors.
 	c := &chart.Chart{}
 
+	if o.GitDirtyCheck {
+		if err := checkGitClean(topdir); err != nil {
+			return c, err
+		}
+	}
+
 	rules := ignore.Empty()
 	ifile := filepath.Join(topdir, ignore.HelmIgnore)
 	if _, err := os.Stat(ifile); err == nil {
@@ -222,9 +1095,11 @@ func LoadDir(dir string) (*chart.Chart, error) {
 		}
 		rules = r
 	}
+	rules.CaseInsensitive = o.CaseInsensitiveIgnore
 	rules.AddDefaults()
 
 	files := []*afile{}
+	limit := &sizeLimiter{max: o.MaxSize}
 	topdir += string(filepath.Separator)
 
 	err = filepath.Walk(topdir, func(name string, fi os.FileInfo, err error) error {
@@ -242,6 +1117,12 @@ func LoadDir(dir string) (*chart.Chart, error) {
 			if rules.Ignore(n, fi) {
 				return filepath.SkipDir
 			}
+			if n != "" && o.MaxDepth > 0 && strings.Count(n, "/")+1 > o.MaxDepth {
+				return filepath.SkipDir
+			}
+			if o.SkipDependencies && n == ChartsDir {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -250,9 +1131,17 @@ func LoadDir(dir string) (*chart.Chart, error) {
 			return nil
 		}
 
+		if err := limit.add(fi.Size()); err != nil {
+			return err
+		}
+
+		start := time.Now()
 		data, err := ioutil.ReadFile(name)
 		if err != nil {
-			return fmt.Errorf("error reading %s: %s", n, err)
+			return &fileReadError{name: n, err: err}
+		}
+		if o.Profile != nil {
+			o.Profile.record(n, start)
 		}
 
 		files = append(files, &afile{name: n, data: data})
@@ -261,6 +1150,13 @@ func LoadDir(dir string) (*chart.Chart, error) {
 	if err != nil {
 		return c, err
 	}
+	if o.Metrics != nil {
+		o.Metrics.files.Add(float64(len(files)))
+	}
 
-	return loadFiles(files)
+	// Packaged subcharts are read from memory, so don't profile their entries.
+	sub := *o
+	sub.Profile = nil
+	sub.EntryOrder = nil
+	return loadFiles(files, &sub)
 }
@@ -17,29 +17,1122 @@ limitations under the License.
 package chartutil
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/any"
+	"golang.org/x/net/context"
 
 	"k8s.io/helm/pkg/proto/hapi/chart"
 )
 
-func TestLoadDir(t *testing.T) {
-	c, err := Load("testdata/frobnitz")
+func TestLoadDir(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	verifyFrobnitz(t, c)
+	verifyChart(t, c)
+	verifyRequirements(t, c)
+}
+
+func TestLoadFile(t *testing.T) {
+	c, err := Load("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	verifyFrobnitz(t, c)
+	verifyChart(t, c)
+	verifyRequirements(t, c)
+}
+
+func TestLoadDirMaxDepth(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tdir)
+
+	if err := ioutil.WriteFile(filepath.Join(tdir, ChartfileName), []byte("name: deep\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := tdir
+	for i := 1; i <= DefaultMaxDepth+1; i++ {
+		dir = filepath.Join(dir, fmt.Sprintf("d%d", i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("level"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := LoadDir(tdir)
+	if err != nil {
+		t.Fatalf("Failed to load chart: %s", err)
+	}
+	if len(c.Files) != DefaultMaxDepth {
+		t.Errorf("Expected %d files, got %d", DefaultMaxDepth, len(c.Files))
+	}
+
+	c, err = LoadDir(tdir, WithMaxDepth(2))
+	if err != nil {
+		t.Fatalf("Failed to load chart: %s", err)
+	}
+	if len(c.Files) != 2 {
+		t.Errorf("Expected 2 files, got %d", len(c.Files))
+	}
+
+	// A limit of zero or less means no limit.
+	for _, n := range []int{0, -1} {
+		c, err = LoadDir(tdir, WithMaxDepth(n))
+		if err != nil {
+			t.Fatalf("Failed to load chart: %s", err)
+		}
+		if len(c.Files) != DefaultMaxDepth+1 {
+			t.Errorf("Expected %d files with WithMaxDepth(%d), got %d", DefaultMaxDepth+1, n, len(c.Files))
+		}
+	}
+}
+
+func TestLoadTarMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"raw/Chart.yaml":          "name: raw\nversion: 0.1.0\n",
+		"raw/values.yaml":         "foo: bar\n",
+		"raw/templates/some.yaml": "kind: Pod\n",
+	} {
+		if err := writeToTar(tw, name, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadTarMetadata(&buf)
+	if err != nil {
+		t.Fatalf("Failed to load metadata: %s", err)
+	}
+	if m.Name != "raw" || m.Version != "0.1.0" {
+		t.Errorf("Unexpected metadata: %v", m)
+	}
+
+	f, err := os.Open("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := LoadTarMetadata(f); err == nil {
+		t.Error("Expected an error loading metadata from a gzipped archive")
+	}
+}
+
+func TestLoadWithOptions(t *testing.T) {
+	for _, name := range []string{"testdata/frobnitz", "testdata/frobnitz-1.2.3.tgz"} {
+		c, err := LoadWithOptions(name, WithSkipDependencies(), WithStrictValues(), WithMaxSize(1<<20))
+		if err != nil {
+			t.Fatalf("Failed to load %s: %s", name, err)
+		}
+		verifyFrobnitz(t, c)
+		if len(c.Dependencies) != 0 {
+			t.Errorf("%s: expected no dependencies, got %d", name, len(c.Dependencies))
+		}
+		for _, f := range c.Files {
+			if strings.HasPrefix(f.TypeUrl, "charts/") {
+				t.Errorf("%s: unexpected file %s", name, f.TypeUrl)
+			}
+		}
+
+		if _, err := LoadWithOptions(name, WithSkipDependencies(), WithMaxSize(64)); err == nil {
+			t.Errorf("%s: expected chart to exceed the maximum size", name)
+		}
+	}
+
+	tdir, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tdir)
+
+	if err := ioutil.WriteFile(filepath.Join(tdir, ChartfileName), []byte("name: strict\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tdir, ValuesfileName), []byte("foo: [bar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWithOptions(tdir); err != nil {
+		t.Errorf("Expected unparseable values to load without WithStrictValues: %s", err)
+	}
+	if _, err := LoadWithOptions(tdir, WithStrictValues(), WithMaxDepth(1)); err == nil {
+		t.Error("Expected unparseable values to fail with WithStrictValues")
+	}
+}
+
+func TestLoadArchiveTimeout(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadArchive(bytes.NewReader(data), WithTimeout(time.Nanosecond)); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	c, err := LoadArchive(bytes.NewReader(data), WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	verifyFrobnitz(t, c)
+}
+
+func TestLoadTruncatedDependency(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tdir)
+
+	if err := ioutil.WriteFile(filepath.Join(tdir, ChartfileName), []byte("name: umbrella\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile("testdata/frobnitz/charts/mariner-4.3.2.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(tdir, ChartsDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tdir, ChartsDir, "foo.tgz"), data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadDir(tdir)
+	if err == nil {
+		t.Fatal("Expected an error loading a truncated dependency")
+	}
+	expect := "failed to load dependency 'foo' (charts/foo.tgz): corrupt tar archive"
+	if !strings.HasPrefix(err.Error(), expect) {
+		t.Errorf("Expected error to start with %q, got %q", expect, err)
+	}
+}
+
+func TestLoadChart(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	for _, name := range []string{
+		srv.URL + "/frobnitz-1.2.3.tgz",
+		"testdata/frobnitz-1.2.3.tgz",
+		"testdata/frobnitz",
+	} {
+		c, err := LoadChart(context.Background(), name)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %s", name, err)
+		}
+		verifyFrobnitz(t, c)
+	}
+
+	if _, err := LoadChart(context.Background(), srv.URL+"/nosuchchart-0.1.0.tgz"); err == nil {
+		t.Error("Expected an error fetching a missing chart")
+	}
+}
+
+func TestLoadCaseCollisions(t *testing.T) {
+	files := map[string]string{
+		"collide/Chart.yaml":             "name: collide\nversion: 0.1.0\n",
+		"collide/templates/Deploy.yaml":  "kind: Deployment\n",
+		"collide/templates/deploy.yaml":  "kind: Deployment\n",
+		"collide/templates/service.yaml": "kind: Service\n",
+	}
+
+	c, err := LoadArchive(makeArchive(t, files))
+	if err != nil {
+		t.Fatalf("Expected case collisions to be a warning: %s", err)
+	}
+	if len(c.Templates) != 3 {
+		t.Errorf("Expected 3 templates, got %d", len(c.Templates))
+	}
+
+	if _, err := LoadArchive(makeArchive(t, files), WithRejectCaseCollisions()); err == nil {
+		t.Error("Expected an error for case-colliding template names")
+	}
+}
+
+func TestLoadSubchartNameMismatch(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	sub := filepath.Join(tmp, "umbrella", "charts", "minnow")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, "umbrella", "Chart.yaml"), []byte("name: umbrella\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(sub, "Chart.yaml"), []byte("name: mariner\nversion: 4.3.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadDir(filepath.Join(tmp, "umbrella"))
+	if err != nil {
+		t.Fatalf("Expected a name mismatch to be a warning: %s", err)
+	}
+	if len(c.Dependencies) != 1 || c.Dependencies[0].Metadata.Name != "mariner" {
+		t.Errorf("Expected mariner dependency, got %v", c.Dependencies)
+	}
+
+	_, err = LoadDir(filepath.Join(tmp, "umbrella"), WithRejectSubchartNameMismatch())
+	if err == nil || !strings.Contains(err.Error(), "charts/minnow") {
+		t.Errorf("Expected an error naming charts/minnow, got %v", err)
+	}
+
+	// The frobnitz subcharts are named correctly.
+	if _, err := LoadDir("testdata/frobnitz", WithRejectSubchartNameMismatch()); err != nil {
+		t.Errorf("Failed to load frobnitz: %s", err)
+	}
+}
+
+func TestLoadAllowedExtensions(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":              "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":             "captain: ahab\n",
+		"ahab/templates/ship.tpl":      "kind: Ship\n",
+		"ahab/README.md":               "# Ahab\n",
+		"ahab/LICENSE":                 "Apache 2.0\n",
+		"ahab/scripts/harpoon.sh":      "#!/bin/sh\necho thar she blows\n",
+		"ahab/charts/whale/Chart.yaml": "name: whale\nversion: 0.1.0\n",
+	}
+
+	_, err := LoadArchive(makeArchive(t, files), WithAllowedExtensions(".md", ".txt", ""))
+	if err == nil || !strings.Contains(err.Error(), "scripts/harpoon.sh") {
+		t.Errorf("Expected scripts/harpoon.sh to be rejected, got %v", err)
+	}
+
+	delete(files, "ahab/scripts/harpoon.sh")
+	c, err := LoadArchive(makeArchive(t, files), WithAllowedExtensions(".MD", ""))
+	if err != nil {
+		t.Fatalf("Expected chart to load: %s", err)
+	}
+	if len(c.Files) != 2 || len(c.Dependencies) != 1 {
+		t.Errorf("Expected 2 files and 1 dependency, got %d and %d", len(c.Files), len(c.Dependencies))
+	}
+
+	files["ahab/charts/whale/scripts/spout.sh"] = "#!/bin/sh\n"
+	if _, err := LoadArchive(makeArchive(t, files), WithAllowedExtensions(".md", "")); err == nil {
+		t.Error("Expected a subchart's .sh file to be rejected")
+	}
+}
+
+func TestLoadStrictMode(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":              "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":             "captain: ahab\n",
+		"ahab/requirements.yaml":       "dependencies: []\n",
+		"ahab/templates/ship.tpl":      "kind: Ship\n",
+		"ahab/charts/whale/Chart.yaml": "name: whale\nversion: 0.1.0\n",
+	}
+	if _, err := LoadArchive(makeArchive(t, files), WithStrictMode()); err != nil {
+		t.Fatalf("Expected chart to load: %s", err)
+	}
+
+	files["ahab/notes/log.txt"] = "Call me Ishmael.\n"
+	_, err := LoadArchive(makeArchive(t, files), WithStrictMode())
+	if e, ok := err.(UnexpectedFileError); !ok || string(e) != "notes/log.txt" {
+		t.Errorf("Expected UnexpectedFileError for notes/log.txt, got %v", err)
+	}
+
+	c, err := LoadArchive(makeArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Files) != 2 {
+		t.Errorf("Expected 2 files without strict mode, got %d", len(c.Files))
+	}
+}
+
+func TestLoadExpandEnv(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":  "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml": "captain: ${CAPTAIN}\nship: ${SHIP}\nfare: $5\n",
+	}
+
+	os.Setenv("HELM_TEST_CAPTAIN", "ahab")
+	defer os.Unsetenv("HELM_TEST_CAPTAIN")
+	env := map[string]string{"CAPTAIN": "ahab", "SHIP": "pequod"}
+
+	c, err := LoadArchive(makeArchive(t, files), WithExpandEnv(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "captain: ahab\nship: pequod\nfare: $5\n"; c.Values.Raw != expect {
+		t.Errorf("Expected %q, got %q", expect, c.Values.Raw)
+	}
+
+	files["ahab/values.yaml"] = "captain: ${HELM_TEST_CAPTAIN}\nship: ${HELM_TEST_UNSET_SHIP}\n"
+	c, err = LoadArchive(makeArchive(t, files), WithExpandEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "captain: ahab\nship: \n"; c.Values.Raw != expect {
+		t.Errorf("Expected %q, got %q", expect, c.Values.Raw)
+	}
+
+	_, err = LoadArchive(makeArchive(t, files), WithExpandEnv(nil), WithErrorOnUnsetEnv())
+	if err == nil || !strings.Contains(err.Error(), "HELM_TEST_UNSET_SHIP") {
+		t.Errorf("Expected an error naming the unset variable, got %v", err)
+	}
+
+	c, err = LoadArchive(makeArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(c.Values.Raw, "${HELM_TEST_CAPTAIN}") {
+		t.Errorf("Expected values to be unexpanded by default, got %q", c.Values.Raw)
+	}
+}
+
+func TestLoadMaxCounts(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":               "name: ahab\nversion: 0.1.0\n",
+		"ahab/templates/ship.yaml":      "kind: Ship\n",
+		"ahab/templates/boat.yaml":      "kind: Boat\n",
+		"ahab/README.md":                "# Ahab\n",
+		"ahab/charts/whale/Chart.yaml":  "name: whale\nversion: 0.1.0\n",
+		"ahab/charts/whale/LICENSE":     "Apache 2.0\n",
+		"ahab/charts/whale/NOTICE":      "Moby\n",
+		"ahab/charts/whale/values.yaml": "spout: true\n",
+	}
+
+	if _, err := LoadArchive(makeArchive(t, files), WithMaxTemplates(2), WithMaxFiles(2)); err != nil {
+		t.Fatalf("Expected chart within limits to load: %s", err)
+	}
+
+	_, err := LoadArchive(makeArchive(t, files), WithMaxTemplates(1))
+	if err == nil || !strings.Contains(err.Error(), "2 templates") {
+		t.Errorf("Expected too many templates, got %v", err)
+	}
+
+	// The limit applies to each chart, so whale's two files are too many.
+	_, err = LoadArchive(makeArchive(t, files), WithMaxFiles(1))
+	if err == nil || !strings.Contains(err.Error(), "chart whale has 2 files") {
+		t.Errorf("Expected too many files in whale, got %v", err)
+	}
+}
+
+func TestLoadTemplateFilter(t *testing.T) {
+	keep := func(name string) bool {
+		return name == "templates/template.tpl"
+	}
+	c, err := LoadWithOptions("testdata/frobnitz", WithTemplateFilter(keep))
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	if len(c.Templates) != 1 || c.Templates[0].Name != "templates/template.tpl" {
+		t.Errorf("Expected only templates/template.tpl, got %v", c.Templates)
+	}
+	if len(c.Files) == 0 {
+		t.Error("Expected files to be loaded")
+	}
+}
+
+func TestLoadStripsBOM(t *testing.T) {
+	const bom = "\xef\xbb\xbf"
+	files := map[string]string{
+		"ahab/Chart.yaml":          bom + "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":         bom + "ship: Pequod\n",
+		"ahab/templates/ship.yaml": bom + "kind: Ship\n",
+		"ahab/README.md":           bom + "# Ahab\n",
+	}
+	c, err := LoadArchive(makeArchive(t, files), WithStrictValues())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.Name != "ahab" {
+		t.Errorf("Expected name ahab, got %q", c.Metadata.Name)
+	}
+	if c.Values.Raw != "ship: Pequod\n" {
+		t.Errorf("Expected values without a BOM, got %q", c.Values.Raw)
+	}
+	vals, err := ReadValues([]byte(c.Values.Raw))
+	if err != nil || vals["ship"] != "Pequod" {
+		t.Errorf("Expected ship to be Pequod, got %v, %v", vals, err)
+	}
+	if string(c.Templates[0].Data) != "kind: Ship\n" {
+		t.Errorf("Expected template without a BOM, got %q", c.Templates[0].Data)
+	}
+	// Other files are kept as they are.
+	if data, _ := GetFile(c, "README.md"); string(data) != bom+"# Ahab\n" {
+		t.Errorf("Expected README to be unchanged, got %q", data)
+	}
+}
+
+func TestLoadReaderPipe(t *testing.T) {
+	for _, format := range []Format{FormatAuto, FormatGzip} {
+		f, err := os.Open("testdata/frobnitz-1.2.3.tgz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			_, err := io.Copy(pw, f)
+			f.Close()
+			pw.CloseWithError(err)
+		}()
+
+		c, err := LoadReader(pr, format)
+		if err != nil {
+			t.Fatalf("Failed to load from pipe with format %d: %s", format, err)
+		}
+		verifyFrobnitz(t, c)
+	}
+}
+
+func TestLoadReaderFormats(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":          "name: ahab\nversion: 0.1.0\n",
+		"ahab/templates/ship.yaml": "kind: Ship\n",
+	}
+
+	zr, err := gzip.NewReader(makeArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, body)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		data   []byte
+		format Format
+	}{
+		{tarball, FormatAuto},
+		{tarball, FormatTar},
+		{zipped.Bytes(), FormatAuto},
+		{zipped.Bytes(), FormatZip},
+	}
+	for _, tt := range tests {
+		c, err := LoadReader(bytes.NewReader(tt.data), tt.format)
+		if err != nil {
+			t.Errorf("Format %d: %s", tt.format, err)
+			continue
+		}
+		if c.Metadata.Name != "ahab" || len(c.Templates) != 1 {
+			t.Errorf("Format %d: expected ahab with one template, got %v", tt.format, c)
+		}
+	}
+
+	if _, err := LoadReader(bytes.NewReader(zipped.Bytes()), FormatZip, WithMaxSize(10)); err == nil {
+		t.Error("Expected a zip archive over the maximum size to fail")
+	}
+	if _, err := LoadReader(bytes.NewReader(tarball), FormatGzip); err == nil {
+		t.Error("Expected an uncompressed archive to fail as gzip")
+	}
+}
+
+func TestLoadSanitizeNames(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":                     "name: ahab\nversion: 0.1.0\n",
+		"ahab\\templates\\my deployment.yaml": "kind: Deployment\n",
+		"ahab/templates/ship?.yaml":           "kind: Ship\n",
+		"ahab/templates/boat.yaml":            "kind: Boat\n",
+	}
+
+	c, err := LoadArchive(makeArchive(t, files), WithSanitizeNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, tpl := range c.Templates {
+		names[tpl.Name] = true
+	}
+	for _, n := range []string{"templates/mydeployment.yaml", "templates/ship.yaml", "templates/boat.yaml"} {
+		if !names[n] {
+			t.Errorf("Expected template %s, got %v", n, names)
+		}
+	}
+	if len(names) != 3 {
+		t.Errorf("Expected 3 templates, got %v", names)
+	}
+
+	// Without the option, the backslashed entry is not in the chart directory.
+	c, err = LoadArchive(makeArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Templates) != 2 {
+		t.Errorf("Expected 2 templates, got %d", len(c.Templates))
+	}
+}
+
+func TestLoadSubchartProvenance(t *testing.T) {
+	moby := makeArchive(t, map[string]string{
+		"moby/Chart.yaml": "name: moby\nversion: 0.1.0\n",
+	})
+	files := map[string]string{
+		"ahab/Chart.yaml":                 "name: ahab\nversion: 0.1.0\n",
+		"ahab/charts/moby-0.1.0.tgz":      moby.String(),
+		"ahab/charts/moby-0.1.0.tgz.prov": "-----BEGIN PGP SIGNED MESSAGE-----\n",
+		"ahab/charts/dick-0.1.0.tgz.prov": "-----BEGIN PGP SIGNED MESSAGE-----\n",
+	}
+
+	c, err := LoadArchive(makeArchive(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetFile(c, "charts/moby-0.1.0.tgz.prov"); !ok {
+		t.Error("Expected the provenance file in the parent by default")
+	}
+
+	c, err = LoadArchive(makeArchive(t, files), WithSubchartProvenance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetFile(c, "charts/moby-0.1.0.tgz.prov"); ok {
+		t.Error("Expected the provenance file to be moved out of the parent")
+	}
+	if _, ok := GetFile(c, "charts/dick-0.1.0.tgz.prov"); !ok {
+		t.Error("Expected a provenance file without a subchart to stay in the parent")
+	}
+	if len(c.Dependencies) != 1 {
+		t.Fatalf("Expected 1 dependency, got %d", len(c.Dependencies))
+	}
+	prov, ok := GetFile(c.Dependencies[0], "moby-0.1.0.tgz.prov")
+	if !ok || string(prov) != files["ahab/charts/moby-0.1.0.tgz.prov"] {
+		t.Errorf("Expected the provenance file in moby, got %q", prov)
+	}
+}
+
+func TestLoadGNULongNames(t *testing.T) {
+	// gnu-longnames.tgz starts with a PAX global header, and its template's
+	// name is too long for a ustar header, so it is stored in a GNU
+	// ././@LongLink entry.
+	long := "templates/" + strings.Repeat("whale", 30) + ".yaml"
+	f, err := os.Open("testdata/gnu-longnames.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// The global header must not be loaded as a file.
+	var entries []string
+	c, err := LoadArchive(f, WithEntryOrder(&entries))
 	if err != nil {
-		t.Fatalf("Failed to load testdata: %s", err)
+		t.Fatal(err)
+	}
+	if len(c.Templates) != 1 || c.Templates[0].Name != long {
+		t.Errorf("Expected template %s, got %v", long, c.Templates)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 entries, got %v", entries)
+	}
+}
+
+func TestLoadArchiveRaw(t *testing.T) {
+	archive, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, raw, err := LoadArchiveRaw(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
 	}
 	verifyFrobnitz(t, c)
-	verifyChart(t, c)
-	verifyRequirements(t, c)
+	if !bytes.Equal(raw, archive) {
+		t.Errorf("Expected the raw archive of %d bytes, got %d bytes", len(archive), len(raw))
+	}
+
+	if _, _, err := LoadArchiveRaw(bytes.NewReader(archive[:100])); err == nil {
+		t.Error("Expected an error for a truncated archive")
+	}
 }
 
-func TestLoadFile(t *testing.T) {
-	c, err := Load("testdata/frobnitz-1.2.3.tgz")
+func TestLoadWithRawArchive(t *testing.T) {
+	archive, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
 	if err != nil {
-		t.Fatalf("Failed to load testdata: %s", err)
+		t.Fatal(err)
+	}
+
+	c, err := LoadArchive(bytes.NewReader(archive), WithRawArchive())
+	if err != nil {
+		t.Fatal(err)
 	}
 	verifyFrobnitz(t, c)
-	verifyChart(t, c)
-	verifyRequirements(t, c)
+	if raw := RawArchive(c); !bytes.Equal(raw, archive) {
+		t.Errorf("Expected the raw archive of %d bytes, got %d bytes", len(archive), len(raw))
+	}
+	for _, dep := range c.Dependencies {
+		// Only mariner is packaged; alpine is a directory.
+		if packaged := dep.Metadata.Name == "mariner"; packaged != (RawArchive(dep) != nil) {
+			t.Errorf("Expected dependency %s to have a raw archive: %t", dep.Metadata.Name, packaged)
+		}
+	}
+
+	c, err = LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := RawArchive(c); raw != nil {
+		t.Errorf("Expected no raw archive without WithRawArchive, got %d bytes", len(raw))
+	}
+}
+
+func TestLoadValidationBundle(t *testing.T) {
+	values, schema, err := LoadValidationBundle("testdata/frobnitz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := ioutil.ReadFile("testdata/frobnitz/values.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(values, expect) || schema != nil {
+		t.Errorf("Expected frobnitz values and no schema, got %q and %q", values, schema)
+	}
+
+	files := map[string]string{
+		"ahab/Chart.yaml":                     "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":                    "\xef\xbb\xbfship: Pequod\n",
+		"ahab/values.schema.json":             `{"type": "object"}`,
+		"ahab/charts/moby/values.schema.json": `{"type": "string"}`,
+		"ahab/templates/values.schema.json":   `{"type": "string"}`,
+	}
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	archive := filepath.Join(tmp, "ahab-0.1.0.tgz")
+	if err := ioutil.WriteFile(archive, makeArchive(t, files).Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	values, schema, err = LoadValidationBundle(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values) != "ship: Pequod\n" || string(schema) != `{"type": "object"}` {
+		t.Errorf("Expected ahab's values and schema, got %q and %q", values, schema)
+	}
+
+	if _, _, err := LoadValidationBundle("testdata/nonexistent"); err == nil {
+		t.Error("Expected an error for a missing chart")
+	}
+}
+
+func TestLoadArchiveLinks(t *testing.T) {
+	makeLinkArchive := func(typeflag byte) *bytes.Buffer {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		if err := writeToTar(tw, "ahab/Chart.yaml", []byte("name: ahab\nversion: 0.1.0\n")); err != nil {
+			t.Fatal(err)
+		}
+		link := &tar.Header{Typeflag: typeflag, Name: "ahab/templates/passwd.yaml", Linkname: "/etc/passwd", Mode: 0777}
+		if err := tw.WriteHeader(link); err != nil {
+			t.Fatal(err)
+		}
+		tw.Close()
+		zw.Close()
+		return &buf
+	}
+
+	for _, typeflag := range []byte{tar.TypeSymlink, tar.TypeLink} {
+		c, err := LoadArchive(makeLinkArchive(typeflag))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Templates) != 0 {
+			t.Errorf("Expected the link to be skipped, got %v", c.Templates)
+		}
+
+		_, err = LoadArchive(makeLinkArchive(typeflag), WithRejectLinks())
+		if err == nil || !strings.Contains(err.Error(), "is a link to /etc/passwd") {
+			t.Errorf("Expected the link to be rejected, got %v", err)
+		}
+	}
+}
+
+func TestRegisterArchiveFormat(t *testing.T) {
+	// A trivial format: the magic bytes followed by an uncompressed tarball.
+	magic := []byte("HELMTAR\x00")
+	defer func(formats []archiveFormat) {
+		archiveFormatsMu.Lock()
+		archiveFormats = formats
+		archiveFormatsMu.Unlock()
+	}(archiveFormats)
+	RegisterArchiveFormat("helmtar", magic, func(in io.Reader) (io.Reader, error) {
+		header := make([]byte, len(magic))
+		if _, err := io.ReadFull(in, header); err != nil {
+			return nil, err
+		}
+		return in, nil
+	})
+
+	var buf bytes.Buffer
+	buf.Write(magic)
+	tw := tar.NewWriter(&buf)
+	if err := writeToTar(tw, "ahab/Chart.yaml", []byte("name: ahab\nversion: 0.1.0\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadArchive(&buf)
+	if err != nil {
+		t.Fatalf("Failed to load custom archive: %s", err)
+	}
+	if c.Metadata.Name != "ahab" {
+		t.Errorf("Expected chart ahab, got %q", c.Metadata.Name)
+	}
+
+	// The built-in gzip format still works.
+	c, err = Load("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyFrobnitz(t, c)
+}
+
+func TestLoadArchiveUnsupportedCompression(t *testing.T) {
+	supported := strings.Join(archiveFormatNames(), ", ")
+	if !strings.HasPrefix(supported, "gzip") {
+		t.Errorf("Expected gzip to be the first supported format, got %q", supported)
+	}
+	tests := []struct {
+		header []byte
+		expect string
+	}{
+		{[]byte("BZh91AY&SY"), "bzip2 detected; supported formats are " + supported},
+		{[]byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00, 0x00}, "xz detected; supported formats are " + supported},
+	}
+	for _, tt := range tests {
+		_, err := LoadArchive(bytes.NewReader(tt.header))
+		if _, ok := err.(UnsupportedCompressionError); !ok {
+			t.Errorf("Expected UnsupportedCompressionError, got %T: %v", err, err)
+			continue
+		}
+		if err.Error() != tt.expect {
+			t.Errorf("Expected %q, got %q", tt.expect, err)
+		}
+	}
+
+	if _, err := LoadArchive(bytes.NewReader([]byte("plain text"))); err != gzip.ErrHeader {
+		t.Errorf("Expected gzip.ErrHeader, got %v", err)
+	}
+}
+
+func TestLoadMetadata(t *testing.T) {
+	m, err := LoadMetadata("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load metadata: %s", err)
+	}
+	verifyChartfile(t, m)
+
+	f, err := os.Open("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err = LoadMetadataFromArchive(f)
+	if err != nil {
+		t.Fatalf("Failed to load metadata from archive: %s", err)
+	}
+	verifyChartfile(t, m)
+
+	if _, err := LoadMetadataFromArchive(makeArchive(t, map[string]string{"nochart/values.yaml": "a: b"})); err == nil {
+		t.Error("Expected an error for an archive without Chart.yaml")
+	}
+}
+
+func TestLoadMultipart(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		filename string
+		ok       bool
+	}{
+		{"frobnitz-1.2.3.tgz", true},
+		{"frobnitz-1.2.3.zip", false},
+	} {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("chart", tt.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := mw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		part, err := multipart.NewReader(&body, mw.Boundary()).NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := LoadMultipart(part)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.filename)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to load chart: %s", tt.filename, err)
+		}
+		verifyFrobnitz(t, c)
+	}
+}
+
+func TestLoadArchiveParallel(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+
+	const workers = 100
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := LoadArchive(bytes.NewReader(data))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if c.Metadata.Name != "frobnitz" {
+				errs <- fmt.Errorf("unexpected chart %q", c.Metadata.Name)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Give exiting goroutines a moment to be reaped before counting.
+	for i := 0; i < 50 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected no leaked goroutines, went from %d to %d", before, after)
+	}
+}
+
+func TestLoadPackagedDependencyExtensions(t *testing.T) {
+	tgz, err := ioutil.ReadFile("testdata/frobnitz/charts/mariner-4.3.2.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(tgz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"mariner-4.3.2.tar.gz", tgz},
+		{"mariner-4.3.2.tar", tarball},
+	} {
+		c, err := LoadArchive(makeArchive(t, map[string]string{
+			"umbrella/Chart.yaml":        "name: umbrella\nversion: 0.1.0\n",
+			"umbrella/charts/" + tt.name: string(tt.data),
+		}))
+		if err != nil {
+			t.Fatalf("%s: failed to load chart: %s", tt.name, err)
+		}
+		if len(c.Dependencies) != 1 || c.Dependencies[0].Metadata.Name != "mariner" {
+			t.Errorf("%s: expected mariner dependency, got %v", tt.name, c.Dependencies)
+		}
+	}
+}
+
+func TestLoadFlat(t *testing.T) {
+	for _, name := range []string{"testdata/frobnitz", "testdata/frobnitz-1.2.3.tgz"} {
+		c, err := LoadWithOptions(name, WithFlatLoad())
+		if err != nil {
+			t.Fatalf("Failed to load %s: %s", name, err)
+		}
+		verifyFrobnitz(t, c)
+		if len(c.Dependencies) != 0 {
+			t.Errorf("%s: expected no dependencies, got %d", name, len(c.Dependencies))
+		}
+
+		data, ok := GetFile(c, "charts/mariner-4.3.2.tgz")
+		if !ok {
+			t.Fatalf("%s: expected the packaged subchart in Files", name)
+		}
+		sc, err := LoadArchive(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: failed to load raw subchart: %s", name, err)
+		}
+		if sc.Metadata.Name != "mariner" {
+			t.Errorf("%s: expected mariner, got %s", name, sc.Metadata.Name)
+		}
+		if _, ok := GetFile(c, "charts/alpine/Chart.yaml"); !ok {
+			t.Errorf("%s: expected the unpacked subchart in Files", name)
+		}
+	}
+}
+
+func TestLoadFlatArchive(t *testing.T) {
+	files := map[string]string{
+		"Chart.yaml":              "name: ahab\nversion: 0.1.0\n",
+		"values.yaml":             "captain: ahab\n",
+		"templates/ship.yaml":     "kind: Ship\n",
+		"charts/whale/Chart.yaml": "name: whale\nversion: 0.1.0\n",
+	}
+	if _, err := LoadArchive(makeArchive(t, files)); err == nil {
+		t.Error("Expected a flat archive to be rejected by default")
+	}
+
+	c, err := LoadArchive(makeArchive(t, files), WithAllowFlatArchive())
+	if err != nil {
+		t.Fatalf("Failed to load flat archive: %s", err)
+	}
+	if c.Metadata.Name != "ahab" || c.Values.Raw != "captain: ahab\n" {
+		t.Errorf("Unexpected chart: %v", c)
+	}
+	if len(c.Templates) != 1 || c.Templates[0].Name != "templates/ship.yaml" {
+		t.Errorf("Unexpected templates: %v", c.Templates)
+	}
+	if len(c.Dependencies) != 1 || c.Dependencies[0].Metadata.Name != "whale" {
+		t.Errorf("Unexpected dependencies: %v", c.Dependencies)
+	}
+
+	// Ordinary archives are unaffected by the option.
+	c, err = LoadWithOptions("testdata/frobnitz-1.2.3.tgz", WithAllowFlatArchive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyFrobnitz(t, c)
+}
+
+func TestLoadArchiveLooseDirectories(t *testing.T) {
+	// An archive from a tool that writes the chart directory as an empty
+	// file, omits other directory entries, and uses absolute names.
+	files := map[string]string{
+		"ahab":                          "",
+		"ahab/templates/":               "",
+		"/ahab/Chart.yaml":              "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":              "captain: ahab\n",
+		"ahab/templates/ship.yaml":      "kind: Ship\n",
+		"/ahab/charts/whale/Chart.yaml": "name: whale\nversion: 0.1.0\n",
+	}
+	c, err := LoadArchive(makeArchive(t, files))
+	if err != nil {
+		t.Fatalf("Failed to load archive: %s", err)
+	}
+	if c.Metadata.Name != "ahab" || c.Values.Raw != "captain: ahab\n" {
+		t.Errorf("Unexpected chart: %v", c)
+	}
+	if len(c.Templates) != 1 || c.Templates[0].Name != "templates/ship.yaml" {
+		t.Errorf("Unexpected templates: %v", c.Templates)
+	}
+	if len(c.Files) != 0 {
+		t.Errorf("Expected no files, got %v", c.Files)
+	}
+	if len(c.Dependencies) != 1 || c.Dependencies[0].Metadata.Name != "whale" {
+		t.Errorf("Unexpected dependencies: %v", c.Dependencies)
+	}
+}
+
+func TestLoadArchiveProgress(t *testing.T) {
+	files := map[string]string{
+		"ahab/Chart.yaml":          "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":         "captain: ahab\n",
+		"ahab/templates/ship.yaml": "kind: Ship\n",
+	}
+	seen := map[string]int64{}
+	c, err := LoadArchiveProgress(makeArchive(t, files), func(name string, size int64) {
+		seen[name] = size
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.Name != "ahab" {
+		t.Errorf("Expected chart ahab, got %q", c.Metadata.Name)
+	}
+	if len(seen) != len(files) {
+		t.Errorf("Expected %d callbacks, got %d", len(files), len(seen))
+	}
+	for name, body := range files {
+		if size, ok := seen[name]; !ok || size != int64(len(body)) {
+			t.Errorf("Expected %s to be reported with size %d, got %d", name, len(body), size)
+		}
+	}
+}
+
+// makeArchive builds a gzipped chart archive from a map of names to contents.
+func makeArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for name, body := range files {
+		if err := writeToTar(tw, name, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
 }
 
 func verifyChart(t *testing.T, c *chart.Chart) {
@@ -156,3 +1249,137 @@ func verifyFrobnitz(t *testing.T, c *chart.Chart) {
 		t.Error("No template data.")
 	}
 }
+
+func BenchmarkLoadValidationBundle(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, _, err := LoadValidationBundle("testdata/frobnitz-1.2.3.tgz"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadFull(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := Load("testdata/frobnitz-1.2.3.tgz"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLoadMetadataTransform(t *testing.T) {
+	calls := 0
+	stamp := WithMetadataTransform(func(m *chart.Metadata) *chart.Metadata {
+		calls++
+		stamped := *m
+		stamped.Keywords = append(m.Keywords, "commit-f00dfeed")
+		if m.Version != "0.1.0" {
+			t.Errorf("Expected parsed metadata, got version %q", m.Version)
+		}
+		return &stamped
+	})
+
+	c, err := LoadArchive(makeArchive(t, map[string]string{
+		"ahab/Chart.yaml": "name: ahab\nversion: 0.1.0\n",
+	}), stamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.Name != "ahab" || len(c.Metadata.Keywords) != 1 || c.Metadata.Keywords[0] != "commit-f00dfeed" {
+		t.Errorf("Expected stamped metadata, got %v", c.Metadata)
+	}
+
+	calls = 0
+	if _, err := LoadArchive(makeArchive(t, map[string]string{
+		"ahab/Chart.yaml": "name: [ahab\n",
+	}), stamp); err == nil {
+		t.Error("Expected invalid Chart.yaml to fail")
+	}
+	if calls != 0 {
+		t.Errorf("Expected no transform of invalid Chart.yaml, got %d calls", calls)
+	}
+
+	_, err = LoadArchive(makeArchive(t, map[string]string{
+		"ahab/Chart.yaml": "name: ahab\nversion: 0.1.0\n",
+	}), WithMetadataTransform(func(*chart.Metadata) *chart.Metadata { return nil }))
+	if err == nil {
+		t.Error("Expected an error when the transform returns nil")
+	}
+}
+
+func TestLoadDirCaseInsensitiveIgnore(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tdir)
+
+	for name, body := range map[string]string{
+		ChartfileName:     "name: ahab\nversion: 0.1.0\n",
+		".helmignore":     "*.bak\n",
+		"log.bak":         "lowered",
+		"LOGBOOK.BAK":     "hoisted",
+		"templates/a.yml": "kind: Ship\n",
+	} {
+		n := filepath.Join(tdir, name)
+		if err := os.MkdirAll(filepath.Dir(n), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(n, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded := func(c *chart.Chart) map[string]bool {
+		names := map[string]bool{}
+		for _, f := range c.Files {
+			names[f.TypeUrl] = true
+		}
+		return names
+	}
+
+	c, err := LoadDir(tdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := loaded(c); files["log.bak"] || !files["LOGBOOK.BAK"] {
+		t.Errorf("Expected only log.bak to be ignored by default, got %v", files)
+	}
+
+	c, err = LoadDir(tdir, WithCaseInsensitiveIgnore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := loaded(c); files["log.bak"] || files["LOGBOOK.BAK"] {
+		t.Errorf("Expected both .bak files to be ignored, got %v", files)
+	}
+}
+
+func TestCheckAPIVersionFields(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	requirements := &any.Any{TypeUrl: "requirements.yaml", Value: []byte("dependencies: []\n")}
+	tests := []struct {
+		metadata *chart.Metadata
+		files    []*any.Any
+		warning  string
+	}{
+		{&chart.Metadata{Name: "ahab"}, []*any.Any{requirements}, ""},
+		{&chart.Metadata{Name: "ahab", ApiVersion: ApiVersionV1, Dependencies: []*chart.Dependency{{Name: "sub"}}}, nil, "Chart.yaml dependencies are ignored"},
+		{&chart.Metadata{Name: "ahab", Type: "library"}, nil, "does not support the type field"},
+		{&chart.Metadata{Name: "ahab", ApiVersion: ApiVersionV2, Type: "library"}, nil, ""},
+		{&chart.Metadata{Name: "ahab", ApiVersion: ApiVersionV2}, []*any.Any{requirements}, "requirements.yaml is ignored"},
+		{&chart.Metadata{Name: "ahab", ApiVersion: "v3"}, nil, `unknown apiVersion "v3"`},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		checkAPIVersionFields(&chart.Chart{Metadata: tt.metadata, Files: tt.files})
+		got := buf.String()
+		if tt.warning == "" && got != "" {
+			t.Errorf("%v: expected no warning, got %q", tt.metadata, got)
+		} else if !strings.Contains(got, tt.warning) {
+			t.Errorf("%v: expected a warning containing %q, got %q", tt.metadata, tt.warning, got)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// LoadInstrumentation holds the Prometheus metrics recorded while loading
+// charts:
+//
+//	load_duration_seconds{type="archive"|"dir"}  histogram of load times
+//	load_files_total                             files read, including those of packaged dependencies
+//	load_errors_total{type="archive"|"dir"}      loads that failed
+type LoadInstrumentation struct {
+	duration *prometheus.HistogramVec
+	files    prometheus.Counter
+	errors   *prometheus.CounterVec
+}
+
+// NewPrometheusLoadInstrumentation creates the chart loading metrics, and
+// registers them with reg. If reg is nil, prometheus.DefaultRegisterer is used.
+//
+// Metrics that are already registered with reg are reused, so this may be
+// called more than once for the same registry.
+func NewPrometheusLoadInstrumentation(reg prometheus.Registerer) (*LoadInstrumentation, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	i := &LoadInstrumentation{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "load_duration_seconds",
+			Help: "Time taken to load a chart.",
+		}, []string{"type"}),
+		files: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "load_files_total",
+			Help: "Number of files read while loading charts.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "load_errors_total",
+			Help: "Number of charts that failed to load.",
+		}, []string{"type"}),
+	}
+
+	c, err := registerOrGet(reg, i.duration)
+	if err != nil {
+		return nil, err
+	}
+	i.duration = c.(*prometheus.HistogramVec)
+	if c, err = registerOrGet(reg, i.files); err != nil {
+		return nil, err
+	}
+	i.files = c.(prometheus.Counter)
+	if c, err = registerOrGet(reg, i.errors); err != nil {
+		return nil, err
+	}
+	i.errors = c.(*prometheus.CounterVec)
+	return i, nil
+}
+
+// registerOrGet registers c with reg, or returns the equivalent collector that
+// is already registered.
+func registerOrGet(reg prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// WithMetrics records chart loading metrics in reg.
+//
+// See LoadInstrumentation for the metrics that are recorded. If the metrics
+// cannot be registered, for example because reg already has different
+// metrics with the same names, a warning is logged and no metrics are
+// recorded. Without this option, no metrics code runs at all.
+func WithMetrics(reg prometheus.Registerer) LoadOption {
+	return func(opts *LoadOptions) {
+		i, err := NewPrometheusLoadInstrumentation(reg)
+		if err != nil {
+			log.Printf("warning: cannot register chart loading metrics: %s", err)
+			return
+		}
+		opts.Metrics = i
+	}
+}
+
+// instrument calls load, recording metrics for a chart of the given type if
+// they are enabled.
+func (o *LoadOptions) instrument(kind string, load func() (*chart.Chart, error)) (*chart.Chart, error) {
+	if o.Metrics == nil {
+		return load()
+	}
+	start := time.Now()
+	c, err := load()
+	o.Metrics.duration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	if err != nil {
+		o.Metrics.errors.WithLabelValues(kind).Inc()
+	}
+	return c, err
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestLoadWithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	if _, err := LoadDir("testdata/frobnitz", WithMetrics(reg)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile("testdata/frobnitz-1.2.3.tgz", WithMetrics(reg)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadArchive(bytes.NewBufferString("not a chart"), WithMetrics(reg)); err == nil {
+		t.Fatal("Expected an error for an invalid archive")
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]*dto.MetricFamily{}
+	for _, f := range families {
+		metrics[f.GetName()] = f
+	}
+
+	durations := map[string]uint64{}
+	for _, m := range metrics["load_duration_seconds"].GetMetric() {
+		durations[m.GetLabel()[0].GetValue()] = m.GetHistogram().GetSampleCount()
+	}
+	if durations["dir"] != 1 || durations["archive"] != 2 {
+		t.Errorf("Expected 1 dir and 2 archive loads, got %v", durations)
+	}
+	if n := metrics["load_files_total"].GetMetric()[0].GetCounter().GetValue(); n == 0 {
+		t.Error("Expected files to be counted")
+	}
+	errs := metrics["load_errors_total"].GetMetric()
+	if len(errs) != 1 || errs[0].GetLabel()[0].GetValue() != "archive" || errs[0].GetCounter().GetValue() != 1 {
+		t.Errorf("Expected 1 archive error, got %v", errs)
+	}
+}
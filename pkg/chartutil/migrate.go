@@ -0,0 +1,184 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const (
+	// chartLockName is the name of the lock file in apiVersion v2 charts.
+	chartLockName = "Chart.lock"
+	// defaultChartType is the chart type assumed when none is given.
+	defaultChartType = "application"
+)
+
+// MigrationNote describes a single change made while migrating a chart.
+type MigrationNote struct {
+	// Field is the Chart.yaml field or the file that was changed.
+	Field string
+	// Message describes the change.
+	Message string
+}
+
+func (n MigrationNote) String() string {
+	return n.Field + ": " + n.Message
+}
+
+// MigrateV1ToV3 returns a copy of c converted from the apiVersion v1 chart
+// format to the apiVersion v2 format used by Helm 3, along with a note for
+// each change that was made.
+//
+// The dependencies in requirements.yaml are moved into Chart.yaml, and
+// requirements.lock is renamed to Chart.lock. The apiVersion is set to v2,
+// the type defaults to application, and the deprecated engine field is
+// removed. Subcharts are left as they are, since v2 charts may depend on
+// v1 charts.
+//
+// Charts that are already at apiVersion v2 are returned unchanged.
+func MigrateV1ToV3(c *chart.Chart) (*chart.Chart, []MigrationNote, error) {
+	if c.Metadata == nil {
+		return nil, nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+	switch c.Metadata.ApiVersion {
+	case ApiVersionV2:
+		return proto.Clone(c).(*chart.Chart), nil, nil
+	case ApiVersionV1, "":
+	default:
+		return nil, nil, fmt.Errorf("unsupported apiVersion %q", c.Metadata.ApiVersion)
+	}
+	if e := c.Metadata.Engine; e != "" && e != "gotpl" {
+		return nil, nil, fmt.Errorf("engine %q is not supported by apiVersion %s charts", e, ApiVersionV2)
+	}
+
+	nc := proto.Clone(c).(*chart.Chart)
+	md := nc.Metadata
+	var notes []MigrationNote
+
+	reqs, err := LoadRequirements(nc)
+	if err != nil && err != ErrRequirementsNotFound {
+		return nil, nil, fmt.Errorf("cannot load %s: %s", requirementsName, err)
+	}
+	if reqs != nil {
+		listed := map[string]bool{}
+		for _, d := range md.Dependencies {
+			listed[d.Name] = true
+		}
+		for _, d := range reqs.Dependencies {
+			if listed[d.Name] {
+				continue
+			}
+			md.Dependencies = append(md.Dependencies, &chart.Dependency{
+				Name:       d.Name,
+				Version:    d.Version,
+				Repository: d.Repository,
+			})
+		}
+		notes = append(notes, MigrationNote{
+			Field:   "dependencies",
+			Message: fmt.Sprintf("moved %d dependencies from %s", len(reqs.Dependencies), requirementsName),
+		})
+	}
+
+	files := nc.Files[:0]
+	for _, f := range nc.Files {
+		switch f.TypeUrl {
+		case requirementsName:
+			notes = append(notes, MigrationNote{Field: requirementsName, Message: "removed"})
+			continue
+		case lockfileName:
+			f = &any.Any{TypeUrl: chartLockName, Value: f.Value}
+			notes = append(notes, MigrationNote{Field: lockfileName, Message: "renamed to " + chartLockName})
+		}
+		files = append(files, f)
+	}
+	nc.Files = files
+
+	if md.Engine != "" {
+		notes = append(notes, MigrationNote{Field: "engine", Message: fmt.Sprintf("removed deprecated field (was %q)", md.Engine)})
+		md.Engine = ""
+	}
+	if md.Type == "" {
+		md.Type = defaultChartType
+		notes = append(notes, MigrationNote{Field: "type", Message: "set to " + defaultChartType})
+	}
+	notes = append(notes, MigrationNote{
+		Field:   "apiVersion",
+		Message: fmt.Sprintf("changed from %q to %q", md.ApiVersion, ApiVersionV2),
+	})
+	md.ApiVersion = ApiVersionV2
+
+	return nc, notes, nil
+}
+
+// tillerAnnotations are the annotations that Helm 2 charts use to describe
+// how Tiller manages a resource.
+var tillerAnnotations = map[string]bool{
+	"helm.sh/chart":              true,
+	"helm.sh/modified-by-tiller": true,
+	"modified-by-tiller":         true,
+}
+
+// ToHelm3 returns a copy of c prepared for Helm 3, along with a note for each
+// change that was made.
+//
+// The chart is migrated with MigrateV1ToV3, and then each Tiller-specific
+// annotation, such as helm.sh/chart, is removed from the templates of the
+// chart and all of its subcharts. As with StripDevAnnotations, only keys in a
+// block mapping under an annotations: line are removed, so the helm.sh/chart
+// label that Helm 3 charts still use is kept. Templates are edited as text,
+// since they cannot be parsed as YAML before rendering, so an annotation
+// written as a flow mapping on one line with others is not found.
+func ToHelm3(c *chart.Chart) (*chart.Chart, []MigrationNote, error) {
+	nc, notes, err := MigrateV1ToV3(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	notes = append(notes, stripTillerAnnotations(nc, "")...)
+	return nc, notes, nil
+}
+
+// stripTillerAnnotations removes the tillerAnnotations from the templates of
+// c and its dependencies, whose paths are reported relative to prefix.
+func stripTillerAnnotations(c *chart.Chart, prefix string) []MigrationNote {
+	var notes []MigrationNote
+	for _, t := range c.Templates {
+		var removed []string
+		t.Data, removed = stripAnnotationLines(t.Data, func(key string) bool {
+			return tillerAnnotations[key]
+		})
+		for _, key := range removed {
+			notes = append(notes, MigrationNote{
+				Field:   prefix + t.Name,
+				Message: "removed annotation " + key,
+			})
+		}
+	}
+	for _, dep := range c.Dependencies {
+		name := "<unknown>"
+		if dep.Metadata != nil {
+			name = dep.Metadata.Name
+		}
+		notes = append(notes, stripTillerAnnotations(dep, prefix+ChartsDir+"/"+name+"/")...)
+	}
+	return notes
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestMigrateV1ToV3(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	c.Metadata.Engine = "gotpl"
+
+	nc, notes, err := MigrateV1ToV3(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.ApiVersion != ApiVersionV1 || len(c.Metadata.Dependencies) != 0 {
+		t.Error("Expected original chart to be unchanged")
+	}
+
+	md := nc.Metadata
+	if md.ApiVersion != ApiVersionV2 {
+		t.Errorf("Expected apiVersion %q, got %q", ApiVersionV2, md.ApiVersion)
+	}
+	if md.Type != "application" {
+		t.Errorf("Expected type application, got %q", md.Type)
+	}
+	if md.Engine != "" {
+		t.Errorf("Expected engine to be removed, got %q", md.Engine)
+	}
+	if len(md.Dependencies) != 2 || md.Dependencies[0].Name != "alpine" || md.Dependencies[1].Repository != "https://example.com/charts" {
+		t.Errorf("Unexpected dependencies: %v", md.Dependencies)
+	}
+	if _, err := LoadRequirements(nc); err != ErrRequirementsNotFound {
+		t.Errorf("Expected requirements.yaml to be removed, got %v", err)
+	}
+	if _, ok := GetFile(nc, "Chart.lock"); !ok {
+		t.Error("Expected requirements.lock to be renamed to Chart.lock")
+	}
+	if len(nc.Dependencies) != len(c.Dependencies) {
+		t.Errorf("Expected %d subcharts, got %d", len(c.Dependencies), len(nc.Dependencies))
+	}
+
+	fields := []string{"dependencies", "requirements.lock", "requirements.yaml", "engine", "type", "apiVersion"}
+	if len(notes) != len(fields) {
+		t.Fatalf("Expected %d notes, got %v", len(fields), notes)
+	}
+	for i, f := range fields {
+		if notes[i].Field != f {
+			t.Errorf("Expected note %d to be for %q, got %q", i, f, notes[i])
+		}
+	}
+
+	// The migrated Chart.yaml should survive a round trip.
+	data, err := yaml.Marshal(nc.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err = UnmarshalChartfile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.ApiVersion != ApiVersionV2 || md.Type != "application" || len(md.Dependencies) != 2 {
+		t.Errorf("Unexpected saved Chart.yaml: %v", md)
+	}
+}
+
+func TestMigrateV1ToV3Unsupported(t *testing.T) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "ahab", ApiVersion: ApiVersionV2}}
+	nc, notes, err := MigrateV1ToV3(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 0 || nc.Metadata.Type != "" {
+		t.Errorf("Expected v2 chart to be left alone, got %v", notes)
+	}
+
+	c.Metadata.ApiVersion = "v0"
+	if _, _, err := MigrateV1ToV3(c); err == nil {
+		t.Error("Expected error for unknown apiVersion")
+	}
+
+	c.Metadata.ApiVersion = ApiVersionV1
+	c.Metadata.Engine = "ahabscript"
+	if _, _, err := MigrateV1ToV3(c); err == nil {
+		t.Error("Expected error for unsupported engine")
+	}
+}
+
+func TestToHelm3(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	c.Templates = append(c.Templates, &chart.Template{
+		Name: "templates/deployment.yaml",
+		Data: []byte(`metadata:
+  name: {{ .Release.Name }}
+  labels:
+    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version }}
+  annotations:
+    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version }}
+    "modified-by-tiller": "true"
+    helm.sh/chart-docs: https://example.com
+spec:
+  selector:
+    matchLabels:
+      helm.sh/chart: {{ .Chart.Name }}
+`),
+	})
+	// A dependency without a Chart.yaml must not stop the migration.
+	c.Dependencies = append(c.Dependencies, &chart.Chart{
+		Templates: []*chart.Template{{Name: "templates/pod.yaml", Data: []byte("annotations:\n  modified-by-tiller: \"true\"\n")}},
+	})
+
+	nc, notes, err := ToHelm3(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc.Metadata.ApiVersion != ApiVersionV2 {
+		t.Errorf("Expected apiVersion %q, got %q", ApiVersionV2, nc.Metadata.ApiVersion)
+	}
+	if _, ok := GetFile(nc, "Chart.lock"); !ok {
+		t.Error("Expected requirements.lock to be renamed to Chart.lock")
+	}
+
+	// The helm.sh/chart label is kept; only the annotation is removed.
+	expect := `metadata:
+  name: {{ .Release.Name }}
+  labels:
+    helm.sh/chart: {{ .Chart.Name }}-{{ .Chart.Version }}
+  annotations:
+    helm.sh/chart-docs: https://example.com
+spec:
+  selector:
+    matchLabels:
+      helm.sh/chart: {{ .Chart.Name }}
+`
+	if got := string(nc.Templates[1].Data); got != expect {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expect, got)
+	}
+	if string(c.Templates[1].Data) == expect {
+		t.Error("Expected original chart to be unchanged")
+	}
+
+	var removed []MigrationNote
+	for _, n := range notes {
+		if n.Field == "templates/deployment.yaml" {
+			removed = append(removed, n)
+		}
+	}
+	if len(removed) != 2 || removed[1].Message != "removed annotation modified-by-tiller" {
+		t.Errorf("Unexpected notes: %v", removed)
+	}
+
+	found := false
+	for _, n := range notes {
+		if n.Field == "charts/<unknown>/templates/pod.yaml" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a note for the dependency without Chart.yaml, got %v", notes)
+	}
+}
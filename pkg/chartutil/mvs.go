@@ -0,0 +1,176 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/semver"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// VersionIndex describes the charts available to MVSResolve.
+type VersionIndex interface {
+	// Versions returns the available versions of the named chart.
+	Versions(name string) ([]string, error)
+	// Requirements returns the dependencies declared by a version of the
+	// named chart.
+	Requirements(name, version string) ([]*Dependency, error)
+}
+
+// mvsNode is a chart version in the requirement graph.
+type mvsNode struct {
+	name    string
+	version *semver.Version
+}
+
+// mvsConstraint is a version constraint and the chart that declared it. A nil
+// check allows any version.
+type mvsConstraint struct {
+	from       string
+	name       string
+	constraint string
+	check      *semver.Constraints
+}
+
+// allows reports whether v satisfies the constraint.
+func (c mvsConstraint) allows(v *semver.Version) bool {
+	return c.check == nil || c.check.Check(v)
+}
+
+// MVSResolve selects a version of each of c's dependencies, direct and
+// transitive, using Minimum Version Selection, the algorithm Go modules use.
+//
+// 'helm dependency update' picks the newest version that satisfies each
+// constraint, so the versions it locks depend on what has been published by
+// the time it runs, and can differ between two runs on the same chart. MVS
+// instead reads each constraint as a minimum: a dependency resolves to the
+// oldest version that satisfies its constraint, and where a chart is required
+// more than once, the newest of those minimums is used. The result only
+// changes when a constraint does, so every environment resolves the same
+// versions.
+//
+// The returned map gives the selected version of each chart by name. An
+// error is returned if a constraint matches no available version, or if a
+// selected version does not satisfy a constraint declared by c or by another
+// selected version, such as when "~1.2.0" and ">=1.3.0" are both required.
+func MVSResolve(c *chart.Chart, index VersionIndex) (map[string]string, error) {
+	if c.Metadata == nil {
+		return nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+	deps, err := LoadDependencies(c)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := map[string]*semver.Version{}
+	visited := map[string]bool{}
+	// constraints records who required what, keyed by the requiring node,
+	// so that those of nodes that are not selected can be disregarded.
+	constraints := map[string][]mvsConstraint{}
+
+	type pending struct {
+		from string
+		deps []*Dependency
+	}
+	root := c.Metadata.Name
+	queue := []pending{{root, deps}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range p.deps {
+			cs := mvsConstraint{from: p.from, name: d.Name, constraint: d.Version}
+			if d.Version != "" {
+				if cs.check, err = semver.NewConstraint(d.Version); err != nil {
+					return nil, fmt.Errorf("invalid version %q for %s in %s", d.Version, d.Name, p.from)
+				}
+			}
+			constraints[p.from] = append(constraints[p.from], cs)
+
+			v, err := minimumVersion(index, cs)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				return nil, fmt.Errorf("no version of %s satisfies %q, required by %s", d.Name, d.Version, p.from)
+			}
+			if cur, ok := selected[d.Name]; !ok || v.GreaterThan(cur) {
+				selected[d.Name] = v
+			}
+
+			n := mvsNode{d.Name, v}
+			if visited[n.key()] {
+				continue
+			}
+			visited[n.key()] = true
+			reqs, err := index.Requirements(d.Name, v.Original())
+			if err != nil {
+				return nil, err
+			}
+			queue = append(queue, pending{n.key(), reqs})
+		}
+	}
+
+	// Only the constraints of the root and the selected versions apply.
+	froms := []string{root}
+	for name, v := range selected {
+		froms = append(froms, mvsNode{name, v}.key())
+	}
+	sort.Strings(froms[1:])
+	for _, from := range froms {
+		for _, cs := range constraints[from] {
+			if v := selected[cs.name]; !cs.allows(v) {
+				return nil, fmt.Errorf("selected version %s of %s does not satisfy %q, required by %s", v.Original(), cs.name, cs.constraint, cs.from)
+			}
+		}
+	}
+
+	versions := make(map[string]string, len(selected))
+	for name, v := range selected {
+		versions[name] = v.Original()
+	}
+	return versions, nil
+}
+
+// key identifies the node, such as "mysql@0.3.0".
+func (n mvsNode) key() string {
+	return n.name + "@" + n.version.Original()
+}
+
+// minimumVersion returns the oldest available version of a chart that meets a
+// constraint, or nil if there is none. Versions that are not valid semantic
+// versions are ignored.
+func minimumVersion(index VersionIndex, cs mvsConstraint) (*semver.Version, error) {
+	available, err := index.Versions(cs.name)
+	if err != nil {
+		return nil, err
+	}
+	var min *semver.Version
+	for _, s := range available {
+		v, err := semver.NewVersion(s)
+		if err != nil || !cs.allows(v) {
+			continue
+		}
+		if min == nil || v.LessThan(min) {
+			min = v
+		}
+	}
+	return min, nil
+}
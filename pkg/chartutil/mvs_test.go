@@ -0,0 +1,103 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// fakeIndex is a VersionIndex of charts by name, then version.
+type fakeIndex map[string]map[string][]*Dependency
+
+func (f fakeIndex) Versions(name string) ([]string, error) {
+	var versions []string
+	for v := range f[name] {
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (f fakeIndex) Requirements(name, version string) ([]*Dependency, error) {
+	return f[name][version], nil
+}
+
+func TestMVSResolve(t *testing.T) {
+	index := fakeIndex{
+		"mysql": {
+			"0.3.0": nil,
+			"0.3.5": nil,
+			"0.4.0": nil,
+		},
+		"wordpress": {
+			"1.0.0": {{Name: "mysql", Version: ">=0.3.0"}},
+			"1.1.0": {{Name: "mysql", Version: ">=0.3.5"}},
+			"2.0.0": {{Name: "mysql", Version: ">=0.4.0"}},
+		},
+		"redis": {
+			"2.0.0": nil,
+			"2.1.0": nil,
+		},
+	}
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "blog",
+			ApiVersion: ApiVersionV2,
+			Dependencies: []*chart.Dependency{
+				{Name: "wordpress", Version: "^1.0.0"},
+				{Name: "mysql", Version: ">=0.3.0"},
+				{Name: "redis"},
+			},
+		},
+	}
+
+	// Newest-matching would give wordpress 1.1.0 and mysql 0.4.0; MVS keeps
+	// to the minimums.
+	versions, err := MVSResolve(c, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{"wordpress": "1.0.0", "mysql": "0.3.0", "redis": "2.0.0"}
+	if !reflect.DeepEqual(versions, expect) {
+		t.Errorf("Expected %v, got %v", expect, versions)
+	}
+
+	// Requiring a newer wordpress raises mysql to what that version needs.
+	c.Metadata.Dependencies[0].Version = "^1.1.0"
+	versions, err = MVSResolve(c, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions["wordpress"] != "1.1.0" || versions["mysql"] != "0.3.5" {
+		t.Errorf("Expected wordpress 1.1.0 and mysql 0.3.5, got %v", versions)
+	}
+
+	// A selected version that breaks another constraint is an error.
+	c.Metadata.Dependencies[1].Version = "~0.3.0"
+	c.Metadata.Dependencies[0].Version = "^2.0.0"
+	if _, err := MVSResolve(c, index); err == nil || !strings.Contains(err.Error(), `does not satisfy "~0.3.0"`) {
+		t.Errorf("Expected an unsatisfied constraint, got %v", err)
+	}
+
+	c.Metadata.Dependencies[0].Version = "^3.0.0"
+	if _, err := MVSResolve(c, index); err == nil || !strings.Contains(err.Error(), "no version of wordpress") {
+		t.Errorf("Expected no matching version, got %v", err)
+	}
+}
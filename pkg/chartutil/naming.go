@@ -0,0 +1,119 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// NamingPolicy decides whether a template file name suits the kind of
+// resource that it defines.
+type NamingPolicy interface {
+	// Check reports whether filename, the base name of a template such as
+	// "deployment.yaml", may define a resource of the given kind.
+	Check(kind, filename string) bool
+}
+
+// NamingViolation is a resource whose template file name does not satisfy a
+// NamingPolicy.
+type NamingViolation struct {
+	// Template is the name of the rendered template, such as
+	// "mychart/templates/svc.yaml".
+	Template string
+	// Kind is the kind of the resource that the template defines.
+	Kind string
+}
+
+// SnakeCasePolicy requires a template to be named after the kind of resource
+// it defines, in snake case: a Deployment in deployment.yaml, and a
+// ServiceAccount in service_account.yaml. The file extension is ignored.
+type SnakeCasePolicy struct{}
+
+// Check implements NamingPolicy.
+func (SnakeCasePolicy) Check(kind, filename string) bool {
+	return strings.TrimSuffix(filename, path.Ext(filename)) == snakeCase(kind)
+}
+
+// snakeCase converts a CamelCase kind to snake case. A run of capitals is
+// treated as one word, so CSIDriver becomes csi_driver.
+func snakeCase(s string) string {
+	r := []rune(s)
+	var b bytes.Buffer
+	for i, c := range r {
+		if unicode.IsUpper(c) && i > 0 {
+			prev := r[i-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// ValidateTemplateNames checks the file names of c's rendered templates
+// against policy, and returns a violation for each resource whose template
+// is not named to suit its kind.
+//
+// rendered is the output of the template engine's Render for c, keyed by
+// template name. Only the templates of c and its dependencies are checked;
+// partials and NOTES.txt are skipped, as are documents that are not valid
+// YAML or have no kind, since linting reports those already. A template that
+// defines several resources is checked once for each of them. Violations are
+// returned in order of template name.
+func ValidateTemplateNames(c *chart.Chart, rendered map[string]string, policy NamingPolicy) []NamingViolation {
+	var names []string
+	walkTemplates(c, "", func(chartPath string, t *chart.Template) bool {
+		if isPartial(t) || path.Base(t.Name) == NotesName {
+			return true
+		}
+		dir := strings.Replace(chartPath, "."+ChartsDir+".", "/"+ChartsDir+"/", -1)
+		if n := path.Join(dir, t.Name); rendered[n] != "" {
+			names = append(names, n)
+		}
+		return true
+	})
+	sort.Strings(names)
+
+	var violations []NamingViolation
+	for _, n := range names {
+		for _, doc := range docSeparator.Split(rendered[n], -1) {
+			if isEmptyDocument(doc) {
+				continue
+			}
+			var obj struct {
+				Kind string `json:"kind"`
+			}
+			if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" {
+				continue
+			}
+			if !policy.Check(obj.Kind, path.Base(n)) {
+				violations = append(violations, NamingViolation{Template: n, Kind: obj.Kind})
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestValidateTemplateNames(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab"},
+		Templates: []*chart.Template{
+			{Name: "templates/deployment.yaml"},
+			{Name: "templates/svc.yaml"},
+			{Name: "templates/_helpers.tpl"},
+			{Name: "templates/NOTES.txt"},
+		},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata: &chart.Metadata{Name: "moby"},
+				Templates: []*chart.Template{
+					{Name: "templates/service_account.yml"},
+				},
+			},
+		},
+	}
+	rendered := map[string]string{
+		"ahab/templates/deployment.yaml":                 "kind: Deployment\n---\nkind: Service\n---\n# empty\n",
+		"ahab/templates/svc.yaml":                        "kind: Service\n",
+		"ahab/templates/_helpers.tpl":                    "kind: Helper\n",
+		"ahab/templates/NOTES.txt":                       "Thar she blows: {{ not yaml",
+		"ahab/charts/moby/templates/service_account.yml": "kind: ServiceAccount\n",
+		"other/templates/pod.yaml":                       "kind: Deployment\n",
+	}
+
+	expect := []NamingViolation{
+		{Template: "ahab/templates/deployment.yaml", Kind: "Service"},
+		{Template: "ahab/templates/svc.yaml", Kind: "Service"},
+	}
+	got := ValidateTemplateNames(c, rendered, SnakeCasePolicy{})
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected violations %v, got %v", expect, got)
+	}
+}
+
+func TestSnakeCasePolicy(t *testing.T) {
+	tests := []struct {
+		kind, filename string
+		expect         bool
+	}{
+		{"Deployment", "deployment.yaml", true},
+		{"ServiceAccount", "service_account.yaml", true},
+		{"HorizontalPodAutoscaler", "horizontal_pod_autoscaler.yaml", true},
+		{"CSIDriver", "csi_driver.yaml", true},
+		{"Deployment", "deploy.yaml", false},
+		{"ServiceAccount", "serviceaccount.yaml", false},
+	}
+	for _, tt := range tests {
+		if got := (SnakeCasePolicy{}).Check(tt.kind, tt.filename); got != tt.expect {
+			t.Errorf("Check(%q, %q): expected %t, got %t", tt.kind, tt.filename, tt.expect, got)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"time"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// DefaultMaxDepth is the default limit on how many directories deep LoadDir
+// will descend below the top of a chart.
+const DefaultMaxDepth = 10
+
+// LoadOption allows specifying various settings configurable by the caller
+// for overriding the defaults used when loading a chart.
+type LoadOption func(*LoadOptions)
+
+// LoadOptions specify optional settings used when loading a chart.
+type LoadOptions struct {
+	// MaxDepth is the maximum directory depth LoadDir will traverse. Zero or
+	// less means there is no limit.
+	MaxDepth int
+	// MaxSize is the maximum number of uncompressed bytes a chart may contain.
+	// Zero means there is no limit.
+	MaxSize int64
+	// MaxTemplates is the maximum number of templates a chart may have. Zero
+	// means there is no limit.
+	MaxTemplates int
+	// MaxFiles is the maximum number of other files a chart may have. Zero
+	// means there is no limit.
+	MaxFiles int
+	// TemplateFilter, if set, reports whether a template should be loaded.
+	TemplateFilter func(name string) bool
+	// SkipDependencies, if set, does not load anything under charts/.
+	SkipDependencies bool
+	// StrictValues, if set, requires values.yaml to be parseable.
+	StrictValues bool
+	// MetadataTransform, if set, replaces the metadata parsed from
+	// Chart.yaml with the metadata it returns.
+	MetadataTransform func(*chart.Metadata) *chart.Metadata
+	// FlatLoad, if set, stores the contents of charts/ as files rather than
+	// loading them as dependencies.
+	FlatLoad bool
+	// SubchartProvenance, if set, stores the provenance file of a packaged
+	// subchart in that subchart's files rather than the parent's.
+	SubchartProvenance bool
+	// RejectCaseCollisions, if set, fails loading when two template names
+	// differ only by case. Otherwise such templates are logged as a warning.
+	RejectCaseCollisions bool
+	// RejectSubchartNameMismatch, if set, fails loading when the name of a
+	// directory under charts/ differs from the name in its Chart.yaml.
+	// Otherwise the mismatch is logged as a warning.
+	RejectSubchartNameMismatch bool
+	// AllowedExtensions, if not empty, lists the only file extensions (such
+	// as ".txt") that files outside templates/ may have.
+	AllowedExtensions []string
+	// CaseInsensitiveIgnore, if set, makes LoadDir match .helmignore
+	// patterns without regard to case.
+	CaseInsensitiveIgnore bool
+	// GitDirtyCheck, if set, makes LoadDir fail when the chart directory has
+	// uncommitted changes in a git working tree.
+	GitDirtyCheck bool
+	// ExpandEnv, if set, replaces ${VAR} references in values.yaml with the
+	// values of environment variables.
+	ExpandEnv bool
+	// Env, if not nil, is used instead of the process environment when
+	// ExpandEnv is set.
+	Env map[string]string
+	// ErrorOnUnsetEnv, if set, fails loading when values.yaml refers to an
+	// unset variable. Otherwise such references expand to "".
+	ErrorOnUnsetEnv bool
+	// AllowFlatArchive, if set, accepts archives whose files are not in a
+	// top-level directory.
+	AllowFlatArchive bool
+	// SanitizeNames, if set, removes unsafe characters from the names of
+	// archive entries.
+	SanitizeNames bool
+	// RawArchive, if set, keeps the bytes of each archive that is loaded.
+	// Use RawArchive to retrieve them.
+	RawArchive bool
+	// RejectLinks, if set, fails loading when an archive contains a symbolic
+	// or hard link. Otherwise links are skipped with a warning.
+	RejectLinks bool
+	// StrictMode, if set, fails loading when a chart contains a file that is
+	// not one Helm knows about.
+	StrictMode bool
+	// Metrics, if set, records how long charts take to load.
+	Metrics *LoadInstrumentation
+	// Profile, if set, records how long each file takes to read.
+	Profile *LoadProfile
+	// EntryOrder, if set, receives the names of an archive's entries in the
+	// order they were read.
+	EntryOrder *[]string
+
+	// Timeout is the maximum time to spend reading a chart archive, including
+	// any packaged subcharts. Zero means there is no limit.
+	Timeout time.Duration
+
+	// deadline is when Timeout expires, set when reading an archive starts.
+	deadline time.Time
+	// onFile, if set, is called as each archive entry is read.
+	onFile func(name string, size int64)
+}
+
+// newLoadOptions returns LoadOptions with defaults set and opts applied.
+func newLoadOptions(opts []LoadOption) *LoadOptions {
+	o := &LoadOptions{
+		MaxDepth: DefaultMaxDepth,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMaxDepth limits the number of directories LoadDir will descend below the
+// chart directory (default = 10).
+//
+// Directories deeper than n are skipped entirely. This guards against
+// recursive directory structures. If n is zero or less, there is no limit,
+// so every directory is loaded however deep it is.
+func WithMaxDepth(n int) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.MaxDepth = n
+	}
+}
+
+// WithMaxSize limits the total uncompressed size, in bytes, of the files in a
+// chart.
+//
+// The limit applies to each chart archive independently, so a packaged
+// subchart may itself be up to n bytes.
+func WithMaxSize(n int64) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.MaxSize = n
+	}
+}
+
+// WithMaxTemplates limits the number of templates in a chart.
+//
+// Like WithMaxSize, the limit applies to each chart in the tree
+// independently. Rendering a chart with a very large number of templates can
+// take a great deal of time and memory, so this guards services that render
+// untrusted charts.
+func WithMaxTemplates(n int) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.MaxTemplates = n
+	}
+}
+
+// WithTemplateFilter loads only the templates for which keep returns true.
+//
+// keep is called with the name of each template relative to its chart, such
+// as "templates/deployment.yaml", for the chart and each of its dependencies.
+// Templates that are not kept do not appear in the chart's Templates, so the
+// result is a partial chart: it is suitable for inspection, but it may not
+// render, or render differently, if a kept template uses one that was
+// skipped.
+func WithTemplateFilter(keep func(name string) bool) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.TemplateFilter = keep
+	}
+}
+
+// WithMaxFiles limits the number of files, other than Chart.yaml, values.yaml,
+// and templates, in a chart.
+//
+// Like WithMaxSize, the limit applies to each chart in the tree independently.
+func WithMaxFiles(n int) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.MaxFiles = n
+	}
+}
+
+// WithProfiling records in dest how long each file of the chart takes to
+// read, to help find the cause of slow loads, for example on a network
+// filesystem.
+//
+// dest.Files is replaced when loading starts. For archives, the time includes
+// decompressing the entry. The entries of packaged subcharts are not
+// recorded, since they are read from memory.
+func WithProfiling(dest *LoadProfile) LoadOption {
+	return func(opts *LoadOptions) {
+		dest.Files = map[string]time.Duration{}
+		opts.Profile = dest
+	}
+}
+
+// WithEntryOrder stores in dest the names of the files in a chart archive, in
+// the order they appear in the archive.
+//
+// Pass the names to SaveArchiveInOrder to write the chart back out with its
+// entries in the same order, as is needed to reproduce an archive for
+// re-signing or verification. Directory entries and the entries of packaged
+// subcharts are not included. Loading a directory leaves dest empty.
+func WithEntryOrder(dest *[]string) LoadOption {
+	return func(opts *LoadOptions) {
+		*dest = nil
+		opts.EntryOrder = dest
+	}
+}
+
+// WithTimeout limits the time spent reading and decompressing a chart archive.
+//
+// If loading takes longer than d, it stops with context.DeadlineExceeded.
+// The deadline is only checked between archive entries, so a single large
+// entry may overrun it. It does not apply to charts loaded from directories.
+func WithTimeout(d time.Duration) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.Timeout = d
+	}
+}
+
+// WithSkipDependencies ignores the contents of the charts/ directory.
+//
+// The resulting chart has no Dependencies, and no charts/ entries in Files.
+func WithSkipDependencies() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.SkipDependencies = true
+	}
+}
+
+// WithStrictValues causes loading to fail if values.yaml is not valid YAML.
+//
+// By default, values are stored unparsed and errors surface at render time.
+func WithStrictValues() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.StrictValues = true
+	}
+}
+
+// WithMetadataTransform changes the metadata of a chart as it is loaded,
+// without changing the chart's source.
+//
+// fn is called with the metadata parsed from Chart.yaml, and the chart gets
+// the metadata that fn returns. This is meant for stamping a chart with build
+// information, such as a commit or pipeline ID, in its description or
+// keywords. fn is only called once Chart.yaml has parsed successfully, so an
+// invalid Chart.yaml is still rejected. It is called for packaged
+// dependencies too; fn can check the chart's name if it should only change
+// one of them. Loading fails if fn returns nil.
+func WithMetadataTransform(fn func(*chart.Metadata) *chart.Metadata) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.MetadataTransform = fn
+	}
+}
+
+// WithRejectCaseCollisions causes loading to fail if two templates have names
+// that differ only by case, such as templates/Deploy.yaml and
+// templates/deploy.yaml.
+//
+// Such charts cannot be extracted faithfully onto a case-insensitive
+// filesystem. Without this option, a warning is logged instead.
+func WithRejectCaseCollisions() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.RejectCaseCollisions = true
+	}
+}
+
+// WithRejectSubchartNameMismatch causes loading to fail if an unpacked
+// subchart's directory under charts/ is not named after the chart in its
+// Chart.yaml.
+//
+// Without this option, a warning is logged instead.
+func WithRejectSubchartNameMismatch() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.RejectSubchartNameMismatch = true
+	}
+}
+
+// WithAllowedExtensions causes loading to fail if the chart has a file whose
+// extension is not one of exts.
+//
+// Extensions include the leading dot, as in ".txt", and are compared without
+// regard to case. Use "" to allow files with no extension, such as LICENSE.
+// Templates, Chart.yaml, values.yaml, and packaged subcharts are always
+// allowed, but the files inside subcharts are checked. This is useful for
+// rejecting executables or other unexpected content in untrusted charts.
+func WithAllowedExtensions(exts ...string) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.AllowedExtensions = append(opts.AllowedExtensions, exts...)
+	}
+}
+
+// WithCaseInsensitiveIgnore makes LoadDir match the patterns in .helmignore
+// without regard to case, so that *.bak also ignores NOTES.BAK.
+//
+// By default patterns are case-sensitive, as they always have been in Helm,
+// even on Windows and macOS, whose file systems usually are not. This has no
+// effect on archives, which have no .helmignore.
+func WithCaseInsensitiveIgnore() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.CaseInsensitiveIgnore = true
+	}
+}
+
+// WithGitDirtyCheck causes LoadDir to fail with a DirtyWorkingTreeError if the
+// chart directory is in a git working tree, and has files that are modified
+// or not yet committed.
+//
+// Packaging such a chart gives an archive that cannot be reproduced from the
+// repository. The check runs 'git status', so git must be installed; it is
+// skipped for directories that are not in a git repository.
+func WithGitDirtyCheck() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.GitDirtyCheck = true
+	}
+}
+
+// WithFlatLoad stores everything under charts/ in the chart's Files, as raw
+// bytes, instead of loading it into Dependencies.
+//
+// Packaged subcharts are not decompressed. This is useful for inspecting a
+// chart or reading its metadata, but a flat-loaded chart cannot be rendered
+// correctly, since its dependencies' templates and values are not loaded.
+func WithFlatLoad() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.FlatLoad = true
+	}
+}
+
+// WithSubchartProvenance stores the provenance file of each packaged subchart
+// with the subchart it signs.
+//
+// By default, a file such as charts/mysql-0.3.0.tgz.prov is kept in the
+// parent chart's Files under that name. With this option it is moved to the
+// Files of the mysql dependency, as mysql-0.3.0.tgz.prov, so that each chart
+// in the tree carries its own provenance. Provenance files that do not match
+// a packaged subchart stay in the parent.
+func WithSubchartProvenance() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.SubchartProvenance = true
+	}
+}
+
+// WithStrictMode causes loading to fail with an UnexpectedFileError if the
+// chart contains a file that Helm does not use.
+//
+// The files Helm uses are Chart.yaml, values.yaml, values.schema.json,
+// requirements.yaml, requirements.lock, .helmignore, and anything under
+// templates/ or charts/. This is incompatible with charts that intentionally
+// store supplementary data, such as a README or configuration read with
+// .Files, in the chart directory.
+func WithStrictMode() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.StrictMode = true
+	}
+}
+
+// WithAllowFlatArchive accepts chart archives that have Chart.yaml at the root
+// of the archive, rather than in a directory named after the chart.
+//
+// Such archives are not produced by 'helm package', but by tools that archive
+// the contents of a chart directory instead of the directory itself. Without
+// this option, loading them fails with "chart yaml not in base directory".
+func WithAllowFlatArchive() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.AllowFlatArchive = true
+	}
+}
+
+// WithRejectLinks makes loading a chart archive fail if it contains a
+// symbolic link or hard link entry.
+//
+// 'helm package' never writes links, so an archive that has them was made by
+// another tool, and may be crafted to point outside the chart when it is
+// extracted. By default such entries are skipped, with a warning, since a
+// link has no content of its own to load.
+func WithRejectLinks() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.RejectLinks = true
+	}
+}
+
+// WithRawArchive keeps a copy of a chart archive, exactly as it was read,
+// that can be retrieved with RawArchive.
+//
+// This lets a caller verify the archive's provenance after loading it,
+// without reading the archive a second time. The copy is made as the archive
+// is read, so the whole archive is held in memory until the chart is garbage
+// collected. The bytes are kept beside the chart rather than in it, so that
+// they are not sent to Tiller or stored in release records. Packaged
+// dependencies keep their own archives too. Charts loaded from a directory
+// have none.
+func WithRawArchive() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.RawArchive = true
+	}
+}
+
+// WithSanitizeNames cleans up the names of the entries in a chart archive
+// before they are loaded.
+//
+// Backslashes, as written by some Windows tools, become forward slashes, and
+// any character other than letters, digits, '.', '_', '/', and '-' is
+// removed, so templates\my deployment.yaml is loaded as
+// templates/mydeployment.yaml. A warning is logged for each name that is
+// changed. This only applies to archives; files in a chart directory are
+// loaded under their own names.
+func WithSanitizeNames() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.SanitizeNames = true
+	}
+}
+
+// WithExpandEnv replaces each ${VAR} reference in values.yaml with the value
+// of the environment variable VAR before the values are stored in the chart.
+//
+// If env is nil, variables are read from the process environment; otherwise
+// they are read from env. Only the ${VAR} form is expanded, so a bare $ in a
+// value is left alone. Unset variables expand to the empty string, unless
+// WithErrorOnUnsetEnv is also given. This applies to the values of subcharts
+// as well.
+//
+// Expansion happens before the values are parsed, so a variable's value can
+// change the structure of the YAML, not just a single value. Only use this
+// with charts from trusted sources: a chart can copy any variable in the
+// environment, including secrets, into its values and so into rendered
+// manifests.
+func WithExpandEnv(env map[string]string) LoadOption {
+	return func(opts *LoadOptions) {
+		opts.ExpandEnv = true
+		opts.Env = env
+	}
+}
+
+// WithErrorOnUnsetEnv causes loading with WithExpandEnv to fail if values.yaml
+// refers to an environment variable that is not set.
+func WithErrorOnUnsetEnv() LoadOption {
+	return func(opts *LoadOptions) {
+		opts.ErrorOnUnsetEnv = true
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OverlayValues applies patch to base using strategic-merge-patch semantics.
+//
+// The rules follow those of 'kubectl apply':
+//
+//   - Maps are merged recursively, with values in patch taking precedence.
+//   - A nil value in patch removes the key from the result.
+//   - Lists are replaced, unless their path is listed in mergeKeys.
+//
+// mergeKeys maps the dot-separated path of a list (e.g. "spec.containers") to
+// the name of the field that identifies its elements (e.g. "name"). Elements of
+// such a list are merged with the base element that has the same key, and
+// appended if there is none. List indices are not part of the path, so the
+// ports of each container would be "spec.containers.ports".
+//
+// Neither base nor patch is modified.
+func OverlayValues(base map[string]interface{}, patch map[string]interface{}, mergeKeys map[string]string) (map[string]interface{}, error) {
+	dst, _ := copyValue(base).(map[string]interface{})
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	return overlayTables(dst, patch, "", mergeKeys)
+}
+
+// overlayTables merges src into dst, which it modifies and returns.
+func overlayTables(dst, src map[string]interface{}, path string, mergeKeys map[string]string) (map[string]interface{}, error) {
+	for key, val := range src {
+		p := key
+		if path != "" {
+			p = path + "." + key
+		}
+
+		if val == nil {
+			delete(dst, key)
+			continue
+		}
+
+		switch sv := val.(type) {
+		case map[string]interface{}:
+			dv, ok := dst[key].(map[string]interface{})
+			if !ok {
+				dst[key] = copyValue(sv)
+				continue
+			}
+			merged, err := overlayTables(dv, sv, p, mergeKeys)
+			if err != nil {
+				return dst, err
+			}
+			dst[key] = merged
+		case []interface{}:
+			mk, ok := mergeKeys[p]
+			dv, isList := dst[key].([]interface{})
+			if !ok || !isList {
+				dst[key] = copyValue(sv)
+				continue
+			}
+			merged, err := overlayLists(dv, sv, p, mk, mergeKeys)
+			if err != nil {
+				return dst, err
+			}
+			dst[key] = merged
+		default:
+			dst[key] = val
+		}
+	}
+	return dst, nil
+}
+
+// overlayLists merges the elements of src into dst, matching them by the mk field.
+func overlayLists(dst, src []interface{}, path, mk string, mergeKeys map[string]string) ([]interface{}, error) {
+	for i, item := range src {
+		si, ok := item.(map[string]interface{})
+		if !ok {
+			return dst, fmt.Errorf("cannot merge %s: element %d is not a table", path, i)
+		}
+		id, ok := si[mk]
+		if !ok {
+			return dst, fmt.Errorf("cannot merge %s: element %d has no merge key %q", path, i, mk)
+		}
+
+		found := false
+		for j, existing := range dst {
+			di, ok := existing.(map[string]interface{})
+			if !ok || !equalValues(di[mk], id) {
+				continue
+			}
+			merged, err := overlayTables(di, si, path, mergeKeys)
+			if err != nil {
+				return dst, err
+			}
+			dst[j] = merged
+			found = true
+			break
+		}
+		if !found {
+			dst = append(dst, copyValue(si))
+		}
+	}
+	return dst, nil
+}
+
+// RemoveDefaults returns the values in vals that differ from defaults.
+//
+// Nested maps are compared key by key, and a map that ends up empty is left
+// out. Lists are compared element by element, and are kept whole if any
+// element differs. Keys that are missing from defaults are always kept. This
+// gives a minimal set of overrides, suitable for keeping in version control.
+//
+// Neither vals nor defaults is modified.
+func RemoveDefaults(vals, defaults map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for key, val := range vals {
+		def, ok := defaults[key]
+		if !ok {
+			out[key] = copyValue(val)
+			continue
+		}
+		vm, isMap := val.(map[string]interface{})
+		dm, defIsMap := def.(map[string]interface{})
+		if isMap && defIsMap {
+			if sub := RemoveDefaults(vm, dm); len(sub) > 0 {
+				out[key] = sub
+			}
+			continue
+		}
+		if !equalValues(val, def) {
+			out[key] = copyValue(val)
+		}
+	}
+	return out
+}
+
+// equalValues reports whether a and b hold the same tables, lists, and scalars.
+func equalValues(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !equalValues(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equalValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// copyValue returns a deep copy of the tables and lists in v.
+//
+// Scalar values are returned as-is.
+func copyValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, val := range vv {
+			m[k] = copyValue(val)
+		}
+		return m
+	case Values:
+		return copyValue(map[string]interface{}(vv))
+	case []interface{}:
+		l := make([]interface{}, len(vv))
+		for i, val := range vv {
+			l[i] = copyValue(val)
+		}
+		return l
+	default:
+		return v
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverlayValues(t *testing.T) {
+	base, err := ReadValues([]byte(`
+spec:
+  replicas: 1
+  paused: true
+  containers:
+    - name: web
+      image: nginx:1.10
+      ports:
+        - name: http
+          containerPort: 80
+    - name: sidecar
+      image: busybox
+  args: ["-v", "-d"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := ReadValues([]byte(`
+spec:
+  replicas: 3
+  paused: null
+  containers:
+    - name: web
+      image: nginx:1.11
+      ports:
+        - name: https
+          containerPort: 443
+    - name: logger
+      image: fluentd
+  args: ["-q"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := ReadValues([]byte(`
+spec:
+  replicas: 3
+  containers:
+    - name: web
+      image: nginx:1.11
+      ports:
+        - name: http
+          containerPort: 80
+        - name: https
+          containerPort: 443
+    - name: sidecar
+      image: busybox
+    - name: logger
+      image: fluentd
+  args: ["-q"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mergeKeys := map[string]string{
+		"spec.containers":       "name",
+		"spec.containers.ports": "name",
+	}
+	out, err := OverlayValues(base, patch, mergeKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(map[string]interface{}(expect), out) {
+		t.Errorf("Expected %v, got %v", expect, out)
+	}
+
+	// The base must not have been modified.
+	spec := base["spec"].(map[string]interface{})
+	if spec["replicas"].(float64) != 1 || len(spec["containers"].([]interface{})) != 2 {
+		t.Errorf("Base values were modified: %v", base)
+	}
+}
+
+func TestOverlayValuesMissingMergeKey(t *testing.T) {
+	base := map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "web"}},
+	}
+	patch := map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"image": "nginx"}},
+	}
+	if _, err := OverlayValues(base, patch, map[string]string{"containers": "name"}); err == nil {
+		t.Error("Expected an error for an element without a merge key")
+	}
+}
+
+func TestOverlayValuesTableMergeKey(t *testing.T) {
+	// Merge keys that are tables or lists are compared by value.
+	base := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"match": map[string]interface{}{"path": "/"}, "backend": "web"},
+			map[string]interface{}{"match": []interface{}{"a", "b"}, "backend": "api"},
+		},
+	}
+	patch := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"match": map[string]interface{}{"path": "/"}, "backend": "cache"},
+			map[string]interface{}{"match": []interface{}{"a", "b", "c"}, "backend": "admin"},
+		},
+	}
+	out, err := OverlayValues(base, patch, map[string]string{"rules": "match"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"match": map[string]interface{}{"path": "/"}, "backend": "cache"},
+			map[string]interface{}{"match": []interface{}{"a", "b"}, "backend": "api"},
+			map[string]interface{}{"match": []interface{}{"a", "b", "c"}, "backend": "admin"},
+		},
+	}
+	if !reflect.DeepEqual(expect, out) {
+		t.Errorf("Expected %v, got %v", expect, out)
+	}
+}
+
+func TestRemoveDefaults(t *testing.T) {
+	defaults, err := ReadValues([]byte(`
+replicas: 1
+image:
+  repository: nginx
+  tag: stable
+ports: [80, 443]
+resources:
+  limits:
+    cpu: 100m
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := ReadValues([]byte(`
+replicas: 1
+image:
+  repository: nginx
+  tag: "1.11"
+ports: [80, 8443]
+resources:
+  limits:
+    cpu: 100m
+debug: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]interface{}{
+		"image": map[string]interface{}{"tag": "1.11"},
+		"ports": []interface{}{float64(80), float64(8443)},
+		"debug": true,
+	}
+	out := RemoveDefaults(vals, defaults)
+	if !reflect.DeepEqual(out, expect) {
+		t.Errorf("Expected %v, got %v", expect, out)
+	}
+
+	if out := RemoveDefaults(defaults, defaults); len(out) != 0 {
+		t.Errorf("Expected no overrides, got %v", out)
+	}
+}
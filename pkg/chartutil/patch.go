@@ -0,0 +1,87 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+// PatchApplyError indicates that an operation in a JSON Patch could not be
+// applied.
+type PatchApplyError struct {
+	// Index is the position of the operation in the patch, starting at 0.
+	Index int
+	// Path is the JSON Pointer the operation refers to, such as "/image/tag".
+	Path string
+	// Err is the reason the operation failed.
+	Err error
+}
+
+func (e *PatchApplyError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s): %s", e.Index, e.Path, e.Err)
+}
+
+// Patch applies a JSON Patch (RFC 6902) to values, returning the result.
+//
+// patch is a JSON array of operations (add, remove, replace, move, copy, and
+// test), which are applied in order. If an operation fails, including a test
+// that does not match, a *PatchApplyError is returned. vals is not modified.
+//
+// Patch complements OverlayValues for tools that describe changes to values
+// as JSON Patch operations rather than as a partial document. As with any
+// values that pass through JSON, numbers in the result are float64.
+func Patch(vals map[string]interface{}, patch []byte) (map[string]interface{}, error) {
+	var ops []json.RawMessage
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("patch is not a JSON array of operations: %s", err)
+	}
+
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+	doc, err := json.Marshal(vals)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operations are applied one at a time so that a failure can be traced
+	// to the operation that caused it.
+	for i, raw := range ops {
+		var op struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(raw, &op); err != nil {
+			return nil, &PatchApplyError{Index: i, Err: err}
+		}
+		p, err := jsonpatch.DecodePatch(append(append([]byte("["), raw...), ']'))
+		if err != nil {
+			return nil, &PatchApplyError{Index: i, Path: op.Path, Err: err}
+		}
+		if doc, err = p.Apply(doc); err != nil {
+			return nil, &PatchApplyError{Index: i, Path: op.Path, Err: err}
+		}
+	}
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(doc, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
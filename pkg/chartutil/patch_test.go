@@ -0,0 +1,82 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	vals := map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "1.11",
+		},
+		"replicas": 1.0,
+		"ports":    []interface{}{80.0},
+		"debug":    true,
+	}
+	patch := []byte(`[
+		{"op": "test", "path": "/image/repository", "value": "nginx"},
+		{"op": "replace", "path": "/image/tag", "value": "1.13"},
+		{"op": "add", "path": "/ports/-", "value": 443},
+		{"op": "remove", "path": "/debug"},
+		{"op": "copy", "from": "/replicas", "path": "/minReplicas"},
+		{"op": "move", "from": "/replicas", "path": "/maxReplicas"}
+	]`)
+
+	got, err := Patch(vals, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "1.13",
+		},
+		"ports":       []interface{}{80.0, 443.0},
+		"minReplicas": 1.0,
+		"maxReplicas": 1.0,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %v, got %v", expect, got)
+	}
+	if vals["image"].(map[string]interface{})["tag"] != "1.11" || vals["debug"] != true {
+		t.Errorf("Expected original values to be unchanged, got %v", vals)
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	vals := map[string]interface{}{"name": "ahab"}
+
+	_, err := Patch(vals, []byte(`[
+		{"op": "add", "path": "/ship", "value": "pequod"},
+		{"op": "test", "path": "/name", "value": "ishmael"}
+	]`))
+	perr, ok := err.(*PatchApplyError)
+	if !ok {
+		t.Fatalf("Expected a PatchApplyError, got %v", err)
+	}
+	if perr.Index != 1 || perr.Path != "/name" {
+		t.Errorf("Expected failure at operation 1 on /name, got %d on %s", perr.Index, perr.Path)
+	}
+
+	if _, err := Patch(vals, []byte(`{"op": "remove", "path": "/name"}`)); err == nil {
+		t.Error("Expected an error for a patch that is not an array")
+	}
+}
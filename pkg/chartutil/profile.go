@@ -0,0 +1,70 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"sort"
+	"time"
+)
+
+// LoadProfile records how long each file of a chart took to read.
+//
+// It is filled in by loading a chart with WithProfiling, and must not be
+// shared by loads that run concurrently.
+type LoadProfile struct {
+	// Files maps the name of each file to the time taken to read it. For a
+	// directory, names are relative to the chart directory; for an archive,
+	// they are the names of the archive's entries.
+	Files map[string]time.Duration
+}
+
+// FileLoadStat is the time taken to read one file.
+type FileLoadStat struct {
+	Name     string
+	Duration time.Duration
+}
+
+// SlowestFiles returns the n files that took longest to read, slowest first.
+// Files that took equally long are ordered by name.
+func (p *LoadProfile) SlowestFiles(n int) []FileLoadStat {
+	stats := make([]FileLoadStat, 0, len(p.Files))
+	for name, d := range p.Files {
+		stats = append(stats, FileLoadStat{Name: name, Duration: d})
+	}
+	sort.Sort(byDuration(stats))
+	if n < len(stats) {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// record adds the time taken to read a file, which started at start.
+func (p *LoadProfile) record(name string, start time.Time) {
+	p.Files[name] = time.Since(start)
+}
+
+// byDuration sorts FileLoadStats from slowest to fastest.
+type byDuration []FileLoadStat
+
+func (s byDuration) Len() int      { return len(s) }
+func (s byDuration) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byDuration) Less(i, j int) bool {
+	if s[i].Duration != s[j].Duration {
+		return s[i].Duration > s[j].Duration
+	}
+	return s[i].Name < s[j].Name
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadProfile(t *testing.T) {
+	for _, name := range []string{"testdata/frobnitz", "testdata/frobnitz-1.2.3.tgz"} {
+		p := &LoadProfile{}
+		if _, err := LoadWithOptions(name, WithProfiling(p)); err != nil {
+			t.Fatalf("Failed to load %s: %s", name, err)
+		}
+		found := false
+		for n := range p.Files {
+			if n == "Chart.yaml" || n == "frobnitz/Chart.yaml" {
+				found = true
+			}
+			if n == "mariner/Chart.yaml" {
+				t.Errorf("%s: expected packaged subchart entries not to be profiled", name)
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected Chart.yaml to be profiled, got %v", name, p.Files)
+		}
+	}
+}
+
+func TestSlowestFiles(t *testing.T) {
+	p := &LoadProfile{Files: map[string]time.Duration{
+		"Chart.yaml":          time.Millisecond,
+		"values.yaml":         3 * time.Millisecond,
+		"templates/pod.yaml":  2 * time.Millisecond,
+		"templates/svc.yaml":  3 * time.Millisecond,
+		"templates/NOTES.txt": 0,
+	}}
+
+	expect := []FileLoadStat{
+		{"templates/svc.yaml", 3 * time.Millisecond},
+		{"values.yaml", 3 * time.Millisecond},
+		{"templates/pod.yaml", 2 * time.Millisecond},
+	}
+	if got := p.SlowestFiles(3); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %v, got %v", expect, got)
+	}
+	if got := p.SlowestFiles(10); len(got) != 5 {
+		t.Errorf("Expected all 5 files, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/provenance/message"
+)
+
+// GenerateProvenance returns a PGP clear-signed provenance block for a
+// packaged chart.
+//
+// archiveBytes is the chart archive that c was packaged as. The block is the
+// same as 'helm package --sign' produces: the chart's metadata, followed by
+// the SHA-256 digest of the archive under the name NAME-VERSION.tgz.
+//
+// Unlike provenance.Signatory, the private key need not be in a keyring file:
+// signer may be any crypto.Signer, such as a key held in a KMS or HSM, as
+// long as it holds an RSA or ECDSA key. keyID is the 16-digit hex ID
+// of the PGP key that signer belongs to. It is recorded as the issuer of the
+// signature, so that the block can be verified against that key in a
+// keyring.
+func GenerateProvenance(c *chart.Chart, archiveBytes []byte, keyID string, signer crypto.Signer) ([]byte, error) {
+	if c.Metadata == nil || c.Metadata.Name == "" || c.Metadata.Version == "" {
+		return nil, errors.New("chart metadata must have a name and version")
+	}
+	id, err := strconv.ParseUint(keyID, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key ID %q: %s", keyID, err)
+	}
+
+	sum := sha256.Sum256(archiveBytes)
+	base := fmt.Sprintf("%s-%s.tgz", c.Metadata.Name, c.Metadata.Version)
+	b, err := message.New(c.Metadata, base, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := signerKey(time.Now(), signer)
+	if err != nil {
+		return nil, err
+	}
+	key.KeyId = id
+	sig, err := message.ClearSign(key, b)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(sig), nil
+}
+
+// signerKey returns a sign-only PGP private key that signs with signer.
+//
+// This is what packet.NewSignerPrivateKey does, but the pinned x/crypto only
+// recognizes public keys held by value there, and panics on the pointers
+// that crypto.Signer implementations return.
+func signerKey(created time.Time, signer crypto.Signer) (*packet.PrivateKey, error) {
+	key := &packet.PrivateKey{PrivateKey: signer}
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		key.PublicKey = *packet.NewRSAPublicKey(created, pub)
+	case *ecdsa.PublicKey:
+		key.PublicKey = *packet.NewECDSAPublicKey(created, pub)
+	default:
+		return nil, fmt.Errorf("unsupported signer key type %T: must be RSA or ECDSA", pub)
+	}
+	return key, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+)
+
+func TestGenerateProvenance(t *testing.T) {
+	c, err := Load("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	archive, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A self-signed test entity, so that the signature can be checked
+	// against a keyring. Its RSA key stands in for a key held elsewhere.
+	entity, err := openpgp.NewEntity("Helm Testing", "", "helm-testing@helm.sh", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, ok := entity.PrivateKey.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		t.Fatalf("Expected an RSA key, got %T", entity.PrivateKey.PrivateKey)
+	}
+	keyID := entity.PrimaryKey.KeyIdString()
+
+	prov, err := GenerateProvenance(c, archive, keyID, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := clearsign.Decode(prov)
+	if block == nil {
+		t.Fatalf("Expected a clear-signed block, got %q", prov)
+	}
+	sum := sha256.Sum256(archive)
+	msg := string(block.Plaintext)
+	for _, want := range []string{
+		"name: frobnitz\n",
+		"version: 1.2.3\n",
+		"\n...\n",
+		"frobnitz-1.2.3.tgz: sha256:" + hex.EncodeToString(sum[:]),
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected provenance to contain %q, got:\n%s", want, msg)
+		}
+	}
+
+	signer, err := openpgp.CheckDetachedSignature(openpgp.EntityList{entity}, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		t.Errorf("Failed to verify signature: %s", err)
+	} else if signer != entity {
+		t.Errorf("Expected the block to be signed by the test entity")
+	}
+
+	if _, err := GenerateProvenance(c, archive, "not-hex", priv); err == nil {
+		t.Error("Expected an error for an invalid key ID")
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Permission is a rule of a Role or ClusterRole in a rendered template.
+type Permission struct {
+	// Kind is "Role" or "ClusterRole".
+	Kind string
+	// Name is the name of the role.
+	Name string
+	// Namespace is the namespace of a Role, if its manifest sets one. It is
+	// always empty for a ClusterRole.
+	Namespace string
+	// APIGroups, Resources, and Verbs are those of the rule.
+	APIGroups []string
+	Resources []string
+	Verbs     []string
+}
+
+// rbacRole is the part of a Role or ClusterRole manifest that
+// ListPermissions reads.
+type rbacRole struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Rules []struct {
+		APIGroups []string `json:"apiGroups"`
+		Resources []string `json:"resources"`
+		Verbs     []string `json:"verbs"`
+	} `json:"rules"`
+}
+
+// ListPermissions returns the RBAC permissions granted by a set of rendered
+// templates, such as the output of the template engine's Render.
+//
+// Each rule of every Role and ClusterRole becomes a Permission. They are
+// sorted by kind and then by role name; the rules of a role keep their order.
+// NOTES.txt and partials are skipped. An error is returned if a template is
+// not valid YAML.
+func ListPermissions(rendered map[string]string) ([]Permission, error) {
+	names := make([]string, 0, len(rendered))
+	for n := range rendered {
+		base := path.Base(n)
+		if base == NotesName || strings.HasPrefix(base, "_") {
+			continue
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	perms := []Permission{}
+	for _, n := range names {
+		for _, doc := range SplitMultiDoc(rendered[n]) {
+			var role rbacRole
+			if err := yaml.Unmarshal([]byte(doc), &role); err != nil {
+				return nil, fmt.Errorf("cannot parse %s: %s", n, err)
+			}
+			if role.Kind != "Role" && role.Kind != "ClusterRole" {
+				continue
+			}
+			for _, r := range role.Rules {
+				perms = append(perms, Permission{
+					Kind:      role.Kind,
+					Name:      role.Metadata.Name,
+					Namespace: role.Metadata.Namespace,
+					APIGroups: r.APIGroups,
+					Resources: r.Resources,
+					Verbs:     r.Verbs,
+				})
+			}
+		}
+	}
+	sort.Stable(permissionsByRole(perms))
+	return perms, nil
+}
+
+// permissionsByRole sorts Permissions by kind and then by role name.
+type permissionsByRole []Permission
+
+func (p permissionsByRole) Len() int      { return len(p) }
+func (p permissionsByRole) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p permissionsByRole) Less(i, j int) bool {
+	if p[i].Kind != p[j].Kind {
+		return p[i].Kind < p[j].Kind
+	}
+	return p[i].Name < p[j].Name
+}
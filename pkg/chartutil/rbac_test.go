@@ -0,0 +1,74 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListPermissions(t *testing.T) {
+	rendered := map[string]string{
+		"ahab/templates/role.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: reader
+  namespace: pequod
+rules:
+  - apiGroups: [""]
+    resources: ["pods", "pods/log"]
+    verbs: ["get", "list"]
+  - apiGroups: ["apps"]
+    resources: ["deployments"]
+    verbs: ["watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: reader
+`,
+		"ahab/templates/clusterrole.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: admin
+rules:
+  - apiGroups: ["*"]
+    resources: ["*"]
+    verbs: ["*"]
+`,
+		"ahab/templates/service.yaml": "apiVersion: v1\nkind: Service\n",
+		"ahab/templates/NOTES.txt":    "Thar she blows: {{ not yaml",
+	}
+
+	perms, err := ListPermissions(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Permission{
+		{Kind: "ClusterRole", Name: "admin", APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+		{Kind: "Role", Name: "reader", Namespace: "pequod", APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get", "list"}},
+		{Kind: "Role", Name: "reader", Namespace: "pequod", APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"watch"}},
+	}
+	if !reflect.DeepEqual(perms, expect) {
+		t.Errorf("Expected %v, got %v", expect, perms)
+	}
+
+	rendered["ahab/templates/bad.yaml"] = "kind: [Role"
+	if _, err := ListPermissions(rendered); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
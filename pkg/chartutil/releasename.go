@@ -0,0 +1,103 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/technosophos/moniker"
+)
+
+// MaxReleaseNameLength is the longest release name Tiller accepts.
+//
+// Kubernetes names are limited to 63 characters, and 10 of those are reserved
+// for charts to add to the release name.
+const MaxReleaseNameLength = 53
+
+// releaseNameHashLength is the number of hex digits of the namespace hash in
+// a computed release name.
+const releaseNameHashLength = 8
+
+// unsafeNameChars matches runs of characters not allowed in a DNS label.
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ReleaseNameOptions customizes the names produced by ComputeReleaseName.
+type ReleaseNameOptions struct {
+	// Prefix, if set, is put before the chart name, such as "ci" for
+	// "ci-mychart-0f1e2d3c".
+	Prefix string
+	// MaxLength is the length to truncate the name to. Zero, or a length
+	// greater than MaxReleaseNameLength, means MaxReleaseNameLength.
+	MaxLength int
+}
+
+// ComputeReleaseName returns a release name for a chart installed in the
+// given namespace.
+//
+// The name is the chart name followed by a short hash of the namespace, so
+// the same chart gets the same name in a namespace every time, and different
+// names in different namespaces. The name is a valid DNS label: it is
+// lowercased, characters other than letters, digits, and '-' become '-', and
+// the chart name (with any prefix) is shortened so that the whole name fits
+// within the maximum length. The hash is never truncated; if the maximum
+// leaves no room for the chart name, the name is the hash alone.
+func ComputeReleaseName(chartName, namespace string, opts ReleaseNameOptions) string {
+	max := opts.MaxLength
+	if max <= 0 || max > MaxReleaseNameLength {
+		max = MaxReleaseNameLength
+	}
+	sum := sha256.Sum256([]byte(namespace))
+	hash := hex.EncodeToString(sum[:])[:releaseNameHashLength]
+
+	base := chartName
+	if opts.Prefix != "" {
+		base = opts.Prefix + "-" + chartName
+	}
+	base = strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(base), "-"), "-")
+	if room := max - len(hash) - 1; len(base) > room {
+		if room < 0 {
+			room = 0
+		}
+		base = strings.TrimRight(base[:room], "-")
+	}
+	if base == "" {
+		return hash
+	}
+	return base + "-" + hash
+}
+
+// RandomReleaseName returns a release name in the style Tiller generates when
+// none is given, such as "wobbly-panda", chosen with a random source seeded
+// with seed.
+//
+// The words come from the same lists as Tiller's names, but the same seed
+// always gives the same name, so tests can assert on the result. The name is
+// truncated to MaxReleaseNameLength, as Tiller does.
+func RandomReleaseName(seed int64) string {
+	r := rand.New(rand.NewSource(seed))
+	descriptor := moniker.Descriptors[r.Intn(len(moniker.Descriptors))]
+	animal := moniker.Animals[r.Intn(len(moniker.Animals))]
+	name := descriptor + "-" + animal
+	if len(name) > MaxReleaseNameLength {
+		name = name[:MaxReleaseNameLength]
+	}
+	return name
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/technosophos/moniker"
+)
+
+func TestComputeReleaseName(t *testing.T) {
+	dnsLabel := regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+	name := ComputeReleaseName("mychart", "default", ReleaseNameOptions{})
+	if name != ComputeReleaseName("mychart", "default", ReleaseNameOptions{}) {
+		t.Error("Expected the same name for the same inputs")
+	}
+	if !strings.HasPrefix(name, "mychart-") || len(name) != len("mychart-")+8 {
+		t.Errorf("Expected mychart followed by a hash, got %q", name)
+	}
+	if other := ComputeReleaseName("mychart", "kube-system", ReleaseNameOptions{}); other == name {
+		t.Errorf("Expected different names for different namespaces, got %q", other)
+	}
+
+	tests := []struct {
+		chart string
+		opts  ReleaseNameOptions
+		base  string
+		max   int
+	}{
+		{"mychart", ReleaseNameOptions{Prefix: "ci"}, "ci-mychart", 53},
+		{"My_Chart.v2", ReleaseNameOptions{}, "my-chart-v2", 53},
+		{strings.Repeat("a", 80), ReleaseNameOptions{}, strings.Repeat("a", 44), 53},
+		{"mychart", ReleaseNameOptions{MaxLength: 12}, "myc", 12},
+		{"ab-cdef", ReleaseNameOptions{MaxLength: 12}, "ab", 12},
+		{"mychart", ReleaseNameOptions{MaxLength: 5}, "", 53},
+	}
+	for _, tt := range tests {
+		got := ComputeReleaseName(tt.chart, "default", tt.opts)
+		if !strings.HasPrefix(got, tt.base) || len(got) > tt.max || !dnsLabel.MatchString(got) {
+			t.Errorf("ComputeReleaseName(%q, %+v): expected a DNS label starting %q of at most %d characters, got %q", tt.chart, tt.opts, tt.base, tt.max, got)
+		}
+	}
+}
+
+func TestRandomReleaseName(t *testing.T) {
+	// The zero seed always picks these words: the first two draws of a
+	// source seeded with 0, indexing the descriptor and animal lists.
+	r := rand.New(rand.NewSource(0))
+	expect := moniker.Descriptors[r.Intn(len(moniker.Descriptors))] + "-" + moniker.Animals[r.Intn(len(moniker.Animals))]
+
+	name := RandomReleaseName(0)
+	if name != expect {
+		t.Errorf("Expected %q for seed 0, got %q", expect, name)
+	}
+	if again := RandomReleaseName(0); again != name {
+		t.Errorf("Expected the same name for the same seed, got %q and %q", name, again)
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		name := RandomReleaseName(seed)
+		parts := strings.SplitN(name, "-", 2)
+		if len(parts) != 2 || !inList(moniker.Descriptors, parts[0]) || !inList(moniker.Animals, parts[1]) {
+			t.Errorf("Expected a descriptor and an animal for seed %d, got %q", seed, name)
+		}
+		if len(name) > MaxReleaseNameLength {
+			t.Errorf("Expected at most %d characters for seed %d, got %q", MaxReleaseNameLength, seed, name)
+		}
+	}
+}
+
+func inList(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
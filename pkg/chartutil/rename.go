@@ -0,0 +1,205 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/golang/protobuf/proto"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// defineAction matches the start of a {{ define }} action, up to its name.
+var defineAction = regexp.MustCompile(`^{{-?\s*define\s+`)
+
+// RenameChart returns a copy of c that has been renamed to newName.
+//
+// Along with Metadata.Name, any template names that are namespaced by the old
+// chart name (e.g. {{ define "oldname.fullname" }} and the matching
+// {{ template "oldname.fullname" . }} or {{ include "oldname.fullname" . }})
+// are renamed, so that helpers keep working. References to {{ .Chart.Name }}
+// need no changes, since they are evaluated at render time. The templates are
+// parsed to find these names, and only the names themselves are rewritten;
+// other strings, the rest of each template, and all other files are
+// untouched. A template that does not parse is returned as a *ParseError.
+func RenameChart(c *chart.Chart, newName string) (*chart.Chart, error) {
+	if c.Metadata == nil {
+		return nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+	if !chartNameRegexp.MatchString(newName) {
+		return nil, fmt.Errorf("invalid chart name %q", newName)
+	}
+
+	oldName := c.Metadata.Name
+	nc := proto.Clone(c).(*chart.Chart)
+	nc.Metadata.Name = newName
+
+	rename := func(name string) (string, bool) {
+		if name == oldName {
+			return newName, true
+		}
+		if strings.HasPrefix(name, oldName+".") {
+			return newName + name[len(oldName):], true
+		}
+		return "", false
+	}
+	for _, t := range nc.Templates {
+		data, err := renameTemplateNames(t, rename)
+		if err != nil {
+			return nil, err
+		}
+		t.Data = data
+	}
+	return nc, nil
+}
+
+// renameTemplateNames returns the contents of t with the names given to
+// define, template, and include changed by rename.
+func renameTemplateNames(t *chart.Template, rename func(string) (string, bool)) ([]byte, error) {
+	trees := map[string]*parse.Tree{}
+	if _, err := parseTemplate(t, trees); err != nil {
+		return nil, newParseError(t, err)
+	}
+
+	// edits maps the offset of each name literal to its replacement.
+	edits := map[int]string{}
+	edit := func(pos int) {
+		n := quotedLen(t.Data[pos:])
+		if n == 0 {
+			return
+		}
+		lit := string(t.Data[pos : pos+n])
+		name, err := strconv.Unquote(lit)
+		if err != nil {
+			return
+		}
+		if newName, ok := rename(name); ok {
+			if lit[0] == '`' {
+				edits[pos] = "`" + newName + "`"
+			} else {
+				edits[pos] = strconv.Quote(newName)
+			}
+		}
+	}
+
+	for name, tree := range trees {
+		walkTemplateNodes(tree.Root, func(node parse.Node) {
+			switch n := node.(type) {
+			case *parse.TemplateNode:
+				// The position of a template action is that of its name.
+				edit(int(n.Pos))
+			case *parse.CommandNode:
+				if len(n.Args) < 2 {
+					return
+				}
+				if id, ok := n.Args[0].(*parse.IdentifierNode); ok && id.Ident == "include" {
+					if s, ok := n.Args[1].(*parse.StringNode); ok {
+						edit(int(s.Pos))
+					}
+				}
+			}
+		})
+		if name == t.Name {
+			continue
+		}
+		// The parser does not record where a define's name is, so look
+		// back from the start of its body for the define action.
+		end := int(tree.Root.Pos)
+		for {
+			i := bytes.LastIndex(t.Data[:end], []byte("{{"))
+			if i < 0 {
+				break
+			}
+			if m := defineAction.FindIndex(t.Data[i:]); m != nil {
+				pos := i + m[1]
+				n := quotedLen(t.Data[pos:])
+				if s, err := strconv.Unquote(string(t.Data[pos : pos+n])); err == nil && s == name {
+					edit(pos)
+					break
+				}
+			}
+			end = i
+		}
+	}
+
+	positions := make([]int, 0, len(edits))
+	for pos := range edits {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+	var out bytes.Buffer
+	last := 0
+	for _, pos := range positions {
+		out.Write(t.Data[last:pos])
+		out.WriteString(edits[pos])
+		last = pos + quotedLen(t.Data[pos:])
+	}
+	out.Write(t.Data[last:])
+	return out.Bytes(), nil
+}
+
+// quotedLen returns the length of the quoted or raw string literal at the
+// start of b, or 0 if there is none.
+func quotedLen(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	switch b[0] {
+	case '`':
+		if i := bytes.IndexByte(b[1:], '`'); i >= 0 {
+			return i + 2
+		}
+	case '"':
+		for i := 1; i < len(b); i++ {
+			switch b[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			case '\n':
+				return 0
+			}
+		}
+	}
+	return 0
+}
+
+// Rename changes the name of c in place to newName, so that Save and
+// SaveArchive package it as newName-VERSION.tgz with newName as the top
+// directory.
+//
+// Only the chart's metadata is changed: template content is not rewritten,
+// so helpers named after the old chart keep their names. Use RenameChart to
+// rename those as well.
+func Rename(c *chart.Chart, newName string) error {
+	if c.Metadata == nil {
+		return errors.New("chart metadata (Chart.yaml) missing")
+	}
+	if !chartNameRegexp.MatchString(newName) {
+		return fmt.Errorf("invalid chart name %q", newName)
+	}
+	c.Metadata.Name = newName
+	return nil
+}
@@ -0,0 +1,53 @@
+//go:build go1.16
+// +build go1.16
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// Actions may only span lines from Go 1.16.
+func TestRenameChartMultilineAction(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab", Version: "0.1.0"},
+		Templates: []*chart.Template{
+			{Name: "templates/pod.yaml", Data: []byte(`labels:
+{{- include
+      "ahab.labels"
+      (dict "context" . "name" "ahab") | nindent 2 }}
+`)},
+		},
+	}
+
+	nc, err := RenameChart(c, "ishmael")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `labels:
+{{- include
+      "ishmael.labels"
+      (dict "context" . "name" "ahab") | nindent 2 }}
+`
+	if got := string(nc.Templates[0].Data); got != expect {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+}
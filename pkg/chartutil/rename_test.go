@@ -0,0 +1,167 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestRenameChart(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab", Version: "0.1.0"},
+		Templates: []*chart.Template{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{- define "ahab.fullname" -}}{{ .Release.Name }}-ahab{{- end -}}`)},
+			{Name: "templates/pod.yaml", Data: []byte(`name: {{ template "ahab.fullname" . }}
+chart: {{ .Chart.Name }}
+captain: ahab
+`)},
+		},
+		Files: []*any.Any{{TypeUrl: "README.md", Value: []byte(`Use {{ template "ahab.fullname" . }}`)}},
+	}
+
+	nc, err := RenameChart(c, "ishmael")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc.Metadata.Name != "ishmael" {
+		t.Errorf("Expected name ishmael, got %s", nc.Metadata.Name)
+	}
+
+	expect := []string{
+		`{{- define "ishmael.fullname" -}}{{ .Release.Name }}-ahab{{- end -}}`,
+		`name: {{ template "ishmael.fullname" . }}
+chart: {{ .Chart.Name }}
+captain: ahab
+`,
+	}
+	for i, tpl := range nc.Templates {
+		if string(tpl.Data) != expect[i] {
+			t.Errorf("Expected %s to be %q, got %q", tpl.Name, expect[i], tpl.Data)
+		}
+	}
+	if got := string(nc.Files[0].Value); got != `Use {{ template "ahab.fullname" . }}` {
+		t.Errorf("Expected files to be untouched, got %q", got)
+	}
+	if c.Metadata.Name != "ahab" || string(c.Templates[1].Data) == expect[1] {
+		t.Error("Expected the original chart to be unmodified")
+	}
+
+	if _, err := RenameChart(c, "Not A Name"); err == nil {
+		t.Error("Expected an error for an invalid name")
+	}
+}
+
+func TestRenameChartLiterals(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "nginx", Version: "0.1.0"},
+		Templates: []*chart.Template{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define ` + "`nginx.labels`" + ` }}app: nginx{{ end }}
+{{- define "other" }}{{ include "nginx.labels" . }}{{ end }}`)},
+			{Name: "templates/pod.yaml", Data: []byte(`image: {{ .Values.image | default "nginx" }}
+labels: {{ include "nginx.labels" . | indent 2 }}
+{{ if eq .Values.kind "nginx.conf" }}{{ template "nginx" . }}{{ end }}
+`)},
+		},
+	}
+
+	nc, err := RenameChart(c, "proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only template names are renamed; other strings, even ones that look
+	// like the chart name, are kept.
+	expect := []string{
+		`{{ define ` + "`proxy.labels`" + ` }}app: nginx{{ end }}
+{{- define "other" }}{{ include "proxy.labels" . }}{{ end }}`,
+		`image: {{ .Values.image | default "nginx" }}
+labels: {{ include "proxy.labels" . | indent 2 }}
+{{ if eq .Values.kind "nginx.conf" }}{{ template "proxy" . }}{{ end }}
+`,
+	}
+	for i, tpl := range nc.Templates {
+		if string(tpl.Data) != expect[i] {
+			t.Errorf("Expected %s to be %q, got %q", tpl.Name, expect[i], tpl.Data)
+		}
+	}
+
+	c.Templates[1].Data = []byte(`{{ include "nginx.labels" . `)
+	if _, err := RenameChart(c, "proxy"); err == nil {
+		t.Error("Expected an error for a template that does not parse")
+	} else if _, ok := err.(*ParseError); !ok {
+		t.Errorf("Expected a *ParseError, got %T: %s", err, err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab", Version: "0.1.0"},
+		Templates: []*chart.Template{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{- define "ahab.fullname" -}}ahab{{- end -}}`)},
+		},
+	}
+	if err := Rename(c, "ishmael"); err != nil {
+		t.Fatal(err)
+	}
+	if string(c.Templates[0].Data) != `{{- define "ahab.fullname" -}}ahab{{- end -}}` {
+		t.Errorf("Expected templates to be untouched, got %q", c.Templates[0].Data)
+	}
+
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	where, err := Save(c, tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(where) != "ishmael-0.1.0.tgz" {
+		t.Errorf("Expected ishmael-0.1.0.tgz, got %s", where)
+	}
+
+	f, err := os.Open(where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []string
+	c2, err := LoadArchive(f, WithEntryOrder(&entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2.Metadata.Name != "ishmael" {
+		t.Errorf("Expected name ishmael, got %s", c2.Metadata.Name)
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e, "ishmael/") {
+			t.Errorf("Expected %s to be under ishmael/", e)
+		}
+	}
+
+	if err := Rename(c, "Not A Name"); err == nil || c.Metadata.Name != "ishmael" {
+		t.Errorf("Expected an invalid name to be rejected, got %v", err)
+	}
+}
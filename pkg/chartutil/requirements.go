@@ -92,6 +92,29 @@ func LoadRequirements(c *chart.Chart) (*Requirements, error) {
 	return r, yaml.Unmarshal(data, r)
 }
 
+// LoadDependencies returns the dependencies that a chart declares.
+//
+// apiVersion v2 charts list their dependencies in Chart.yaml, and any
+// requirements.yaml is ignored. Other charts list them in requirements.yaml.
+// A chart that declares no dependencies gives an empty list.
+func LoadDependencies(c *chart.Chart) ([]*Dependency, error) {
+	deps := []*Dependency{}
+	if APIVersion(c) == ApiVersionV2 {
+		for _, d := range c.Metadata.Dependencies {
+			deps = append(deps, &Dependency{Name: d.Name, Version: d.Version, Repository: d.Repository})
+		}
+		return deps, nil
+	}
+
+	reqs, err := LoadRequirements(c)
+	if err == ErrRequirementsNotFound {
+		return deps, nil
+	} else if err != nil {
+		return deps, err
+	}
+	return append(deps, reqs.Dependencies...), nil
+}
+
 // LoadRequirementsLock loads a requirements lock file.
 func LoadRequirementsLock(c *chart.Chart) (*RequirementsLock, error) {
 	var data []byte
@@ -16,6 +16,8 @@ package chartutil
 
 import (
 	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
 )
 
 func TestLoadRequirements(t *testing.T) {
@@ -33,3 +35,40 @@ func TestLoadRequirementsLock(t *testing.T) {
 	}
 	verifyRequirementsLock(t, c)
 }
+
+func TestLoadDependencies(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	if v := APIVersion(c); v != ApiVersionV1 {
+		t.Errorf("Expected apiVersion %s, got %s", ApiVersionV1, v)
+	}
+	deps, err := LoadDependencies(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 2 || deps[0].Name != "alpine" || deps[1].Name != "mariner" {
+		t.Errorf("Expected alpine and mariner from requirements.yaml, got %v", deps)
+	}
+
+	// A v2 chart takes its dependencies from Chart.yaml, even though the
+	// requirements.yaml file is still present.
+	c.Metadata.ApiVersion = ApiVersionV2
+	c.Metadata.Dependencies = []*chart.Dependency{{Name: "whale", Version: "1.0.0", Repository: "https://example.com/charts"}}
+	if v := APIVersion(c); v != ApiVersionV2 {
+		t.Errorf("Expected apiVersion %s, got %s", ApiVersionV2, v)
+	}
+	deps, err = LoadDependencies(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].Name != "whale" || deps[0].Repository != "https://example.com/charts" {
+		t.Errorf("Expected whale from Chart.yaml, got %v", deps)
+	}
+
+	deps, err = LoadDependencies(&chart.Chart{Metadata: &chart.Metadata{Name: "lonely"}})
+	if err != nil || len(deps) != 0 {
+		t.Errorf("Expected no dependencies, got %v (%v)", deps, err)
+	}
+}
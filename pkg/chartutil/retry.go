@@ -0,0 +1,78 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"math/rand"
+	"os"
+	"syscall"
+	"time"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// LoadDirWithRetry loads a chart directory like LoadDir, retrying when a file
+// cannot be read because of a transient error.
+//
+// Some network filesystems, such as NFS and CIFS, occasionally fail to read a
+// file that exists with ENOENT, EIO, or ESTALE. When LoadDir fails with one
+// of those errors, the whole directory is loaded again, up to maxAttempts
+// times in all, waiting backoff plus a random jitter of up to half of backoff
+// between attempts. Any other error, such as permission denied, is returned
+// at once, as is the last error if every attempt fails.
+func LoadDirWithRetry(dir string, maxAttempts int, backoff time.Duration, opts ...LoadOption) (*chart.Chart, error) {
+	return retryLoad(func() (*chart.Chart, error) {
+		return LoadDir(dir, opts...)
+	}, maxAttempts, backoff, time.Sleep)
+}
+
+// retryLoad calls load until it succeeds, fails with an error that is not
+// transient, or has been called maxAttempts times.
+func retryLoad(load func() (*chart.Chart, error), maxAttempts int, backoff time.Duration, sleep func(time.Duration)) (*chart.Chart, error) {
+	for attempt := 1; ; attempt++ {
+		c, err := load()
+		if err == nil || attempt >= maxAttempts || !isTransient(err) {
+			return c, err
+		}
+		wait := backoff
+		if backoff > 0 {
+			wait += time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+		}
+		sleep(wait)
+	}
+}
+
+// isTransient reports whether err is a filesystem error that may go away if
+// the operation is retried.
+func isTransient(err error) bool {
+	for {
+		switch e := err.(type) {
+		case *fileReadError:
+			err = e.err
+		case *os.PathError:
+			err = e.Err
+		case *os.LinkError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		case syscall.Errno:
+			return e == syscall.ENOENT || e == syscall.EIO || e == syscall.ESTALE
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestRetryLoad(t *testing.T) {
+	eio := &fileReadError{name: "Chart.yaml", err: &os.PathError{Op: "open", Path: "/charts/ahab/Chart.yaml", Err: syscall.EIO}}
+	denied := &os.PathError{Op: "open", Path: "/charts/ahab/values.yaml", Err: syscall.EACCES}
+	invalid := errors.New("chart metadata (Chart.yaml) missing")
+	want := &chart.Chart{Metadata: &chart.Metadata{Name: "ahab"}}
+
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		calls    int
+		err      error
+	}{
+		{"succeeds after transient errors", []error{eio, eio}, 3, 3, nil},
+		{"gives up after max attempts", []error{eio, eio, eio}, 3, 3, eio},
+		{"permission denied is not retried", []error{denied}, 3, 1, denied},
+		{"other errors are not retried", []error{invalid}, 3, 1, invalid},
+		{"zero attempts loads once", []error{eio}, 0, 1, eio},
+	}
+	for _, tt := range tests {
+		calls := 0
+		var waits []time.Duration
+		load := func() (*chart.Chart, error) {
+			calls++
+			if calls <= len(tt.errs) {
+				return nil, tt.errs[calls-1]
+			}
+			return want, nil
+		}
+		sleep := func(d time.Duration) { waits = append(waits, d) }
+
+		c, err := retryLoad(load, tt.attempts, 10*time.Millisecond, sleep)
+		if calls != tt.calls {
+			t.Errorf("%s: expected %d calls, got %d", tt.name, tt.calls, calls)
+		}
+		if err != tt.err {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+		}
+		if err == nil && c != want {
+			t.Errorf("%s: expected the loaded chart, got %v", tt.name, c)
+		}
+		if len(waits) != calls-1 {
+			t.Errorf("%s: expected a wait between attempts, got %v", tt.name, waits)
+		}
+		for _, w := range waits {
+			if w < 10*time.Millisecond || w > 15*time.Millisecond {
+				t.Errorf("%s: expected a wait of 10-15ms, got %s", tt.name, w)
+			}
+		}
+	}
+}
+
+func TestLoadDirWithRetry(t *testing.T) {
+	c, err := LoadDirWithRetry("testdata/frobnitz", 3, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	verifyFrobnitz(t, c)
+
+	if _, err := LoadDirWithRetry("testdata/nonexistent", 2, time.Millisecond); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
@@ -21,9 +21,11 @@ import (
 	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/ghodss/yaml"
 
@@ -94,7 +96,7 @@ func SaveDir(c *chart.Chart, dest string) error {
 // will generate /foo/bar-1.0.0.tgz.
 //
 // This returns the absolute path to the chart archive file.
-func Save(c *chart.Chart, outDir string) (string, error) {
+func Save(c *chart.Chart, outDir string) (filename string, err error) {
 	// Create archive
 	if fi, err := os.Stat(outDir); err != nil {
 		return "", err
@@ -113,78 +115,159 @@ func Save(c *chart.Chart, outDir string) (string, error) {
 		return "", errors.New("no chart version specified (Chart.yaml)")
 	}
 
-	filename := fmt.Sprintf("%s-%s.tgz", cfile.Name, cfile.Version)
+	filename = fmt.Sprintf("%s-%s.tgz", cfile.Name, cfile.Version)
 	filename = filepath.Join(outDir, filename)
 	f, err := os.Create(filename)
 	if err != nil {
 		return "", err
 	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(filename)
+		}
+	}()
 
-	// Wrap in gzip writer
-	zipper := gzip.NewWriter(f)
-	zipper.Header.Extra = headerBytes
-	zipper.Header.Comment = "Helm"
+	return filename, SaveArchive(c, f)
+}
 
-	// Wrap in tar writer
-	twriter := tar.NewWriter(zipper)
-	rollback := false
+// SaveArchive writes c to w as a gzipped tar archive, as Save does.
+//
+// Entries are written in order of name, with no timestamps, so saving the
+// same chart always produces the same bytes, whichever filesystem or loader
+// it came from. This makes packaging reproducible.
+func SaveArchive(c *chart.Chart, w io.Writer) (err error) {
+	if c.Metadata == nil {
+		return errors.New("no Chart.yaml data")
+	}
+	a := NewArchiveWriter(w, c.Metadata.Name)
 	defer func() {
-		twriter.Close()
-		zipper.Close()
-		f.Close()
-		if rollback {
-			os.Remove(filename)
+		if cerr := a.Close(); err == nil {
+			err = cerr
 		}
 	}()
+	return writeTarContents(a.twriter, c, "")
+}
 
-	if err := writeTarContents(twriter, c, ""); err != nil {
-		rollback = true
+// SaveArchiveInOrder writes c to w as a gzipped tar archive, like
+// SaveArchive, but with its entries in the given order.
+//
+// order lists entry names, such as those recorded by loading an archive with
+// WithEntryOrder. Entries that are not in order are written afterwards, in
+// order of name. Only the order of entries is preserved: headers are written
+// as SaveArchive writes them, so an archive made by another tool may still
+// differ in other ways.
+func SaveArchiveInOrder(c *chart.Chart, w io.Writer, order []string) (err error) {
+	if c.Metadata == nil {
+		return errors.New("no Chart.yaml data")
 	}
-	return filename, err
+	a := NewArchiveWriter(w, c.Metadata.Name)
+	defer func() {
+		if cerr := a.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	return writeTarContentsInOrder(a.twriter, c, order)
 }
 
+// writeTarContents writes c and its dependencies to out, in order of name.
 func writeTarContents(out *tar.Writer, c *chart.Chart, prefix string) error {
+	entries, err := tarEntries(c, prefix)
+	if err != nil {
+		return err
+	}
+	sort.Sort(afilesByName(entries))
+	for _, e := range entries {
+		if err := writeToTar(out, e.name, e.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTarContentsInOrder writes c and its dependencies to out, first in the
+// given order, and then in order of name.
+func writeTarContentsInOrder(out *tar.Writer, c *chart.Chart, order []string) error {
+	entries, err := tarEntries(c, "")
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*afile, len(entries))
+	for _, e := range entries {
+		byName[e.name] = e
+	}
+	for _, n := range order {
+		e, ok := byName[n]
+		if !ok {
+			continue
+		}
+		if err := writeToTar(out, e.name, e.data); err != nil {
+			return err
+		}
+		delete(byName, n)
+	}
+
+	rest := make([]*afile, 0, len(byName))
+	for _, e := range byName {
+		rest = append(rest, e)
+	}
+	sort.Sort(afilesByName(rest))
+	for _, e := range rest {
+		if err := writeToTar(out, e.name, e.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afilesByName sorts archive entries by name.
+type afilesByName []*afile
+
+func (a afilesByName) Len() int           { return len(a) }
+func (a afilesByName) Less(i, j int) bool { return a[i].name < a[j].name }
+func (a afilesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// tarEntries returns the archive entries for c and its dependencies, below
+// prefix.
+func tarEntries(c *chart.Chart, prefix string) ([]*afile, error) {
 	base := filepath.Join(prefix, c.Metadata.Name)
 
 	// Save Chart.yaml
 	cdata, err := yaml.Marshal(c.Metadata)
 	if err != nil {
-		return err
-	}
-	if err := writeToTar(out, base+"/Chart.yaml", cdata); err != nil {
-		return err
+		return nil, err
 	}
+	entries := []*afile{{name: base + "/Chart.yaml", data: cdata}}
 
 	// Save values.yaml
 	if c.Values != nil && len(c.Values.Raw) > 0 {
-		if err := writeToTar(out, base+"/values.yaml", []byte(c.Values.Raw)); err != nil {
-			return err
-		}
+		entries = append(entries, &afile{name: base + "/values.yaml", data: []byte(c.Values.Raw)})
 	}
 
 	// Save templates
 	for _, f := range c.Templates {
-		n := filepath.Join(base, f.Name)
-		if err := writeToTar(out, n, f.Data); err != nil {
-			return err
-		}
+		entries = append(entries, &afile{name: filepath.Join(base, f.Name), data: f.Data})
 	}
 
 	// Save files
 	for _, f := range c.Files {
-		n := filepath.Join(base, f.TypeUrl)
-		if err := writeToTar(out, n, f.Value); err != nil {
-			return err
-		}
+		entries = append(entries, &afile{name: filepath.Join(base, f.TypeUrl), data: f.Value})
 	}
 
 	// Save dependencies
 	for _, dep := range c.Dependencies {
-		if err := writeTarContents(out, dep, base+"/charts"); err != nil {
-			return err
+		if dep.Metadata == nil {
+			return nil, fmt.Errorf("dependency of %s has no Chart.yaml data", c.Metadata.Name)
+		}
+		de, err := tarEntries(dep, base+"/charts")
+		if err != nil {
+			return nil, err
 		}
+		entries = append(entries, de...)
 	}
-	return nil
+	return entries, nil
 }
 
 // writeToTar writes a single file to a tar archive.
@@ -203,3 +286,48 @@ func writeToTar(out *tar.Writer, name string, body []byte) error {
 	}
 	return nil
 }
+
+// ArchiveWriter builds a gzipped chart archive one file at a time. It is also
+// how Save, SaveArchive, and SaveArchiveInOrder write their archives.
+//
+// Each file is written to the underlying writer as it is added, so a large
+// chart can be packaged without holding all of it in memory. The archive is
+// not complete until Close has been called.
+type ArchiveWriter struct {
+	base    string
+	zipper  *gzip.Writer
+	twriter *tar.Writer
+}
+
+// NewArchiveWriter returns an ArchiveWriter that writes to w an archive of
+// the chart with the given name.
+//
+// The name is used as the archive's top-level directory, and should match the
+// name in the chart's Chart.yaml.
+func NewArchiveWriter(w io.Writer, name string) *ArchiveWriter {
+	zipper := gzip.NewWriter(w)
+	zipper.Header.Extra = headerBytes
+	zipper.Header.Comment = "Helm"
+	return &ArchiveWriter{
+		base:    name,
+		zipper:  zipper,
+		twriter: tar.NewWriter(zipper),
+	}
+}
+
+// AddFile writes a file to the archive.
+//
+// The name is relative to the top of the chart, as in "Chart.yaml" or
+// "templates/service.yaml".
+func (a *ArchiveWriter) AddFile(name string, data []byte) error {
+	return writeToTar(a.twriter, a.base+"/"+filepath.ToSlash(name), data)
+}
+
+// Close finishes the archive. It does not close the underlying writer.
+func (a *ArchiveWriter) Close() error {
+	err := a.twriter.Close()
+	if zerr := a.zipper.Close(); err == nil {
+		err = zerr
+	}
+	return err
+}
@@ -17,8 +17,14 @@ limitations under the License.
 package chartutil
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -66,6 +72,30 @@ func TestSave(t *testing.T) {
 	}
 }
 
+func TestSaveRemovesPartialArchive(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:    "ahab",
+			Version: "1.2.3",
+		},
+		Dependencies: []*chart.Chart{{}},
+	}
+
+	where, err := Save(c, tmp)
+	if err == nil {
+		t.Fatal("Expected an error for a dependency without metadata")
+	}
+	if _, err := os.Stat(where); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", where, err)
+	}
+}
+
 func TestSaveDir(t *testing.T) {
 	tmp, err := ioutil.TempDir("", "helm-")
 	if err != nil {
@@ -99,3 +129,174 @@ func TestSaveDir(t *testing.T) {
 		t.Fatal("Values data did not match")
 	}
 }
+
+func TestArchiveWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewArchiveWriter(&buf, "ahab")
+	files := []struct {
+		name string
+		data string
+	}{
+		{"Chart.yaml", "name: ahab\nversion: 1.2.3\n"},
+		{"values.yaml", "ship: Pequod"},
+		{"templates/whale.yaml", "kind: Whale"},
+		{"README.md", "# Ahab"},
+	}
+	for _, f := range files {
+		if err := w.AddFile(f.name, []byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.Name != "ahab" || c.Metadata.Version != "1.2.3" {
+		t.Errorf("Unexpected metadata: %v", c.Metadata)
+	}
+	if c.Values.Raw != "ship: Pequod" {
+		t.Errorf("Unexpected values: %q", c.Values.Raw)
+	}
+	if len(c.Templates) != 1 || string(c.Templates[0].Data) != "kind: Whale" {
+		t.Errorf("Unexpected templates: %v", c.Templates)
+	}
+	if len(c.Files) != 1 || c.Files[0].TypeUrl != "README.md" {
+		t.Errorf("Unexpected files: %v", c.Files)
+	}
+}
+
+func TestSaveArchiveDeterministic(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+
+	var first, second bytes.Buffer
+	if err := SaveArchive(c, &first); err != nil {
+		t.Fatal(err)
+	}
+	// Reverse the order of the chart's contents; the archive should not change.
+	for i, j := 0, len(c.Files)-1; i < j; i, j = i+1, j-1 {
+		c.Files[i], c.Files[j] = c.Files[j], c.Files[i]
+	}
+	for i, j := 0, len(c.Dependencies)-1; i < j; i, j = i+1, j-1 {
+		c.Dependencies[i], c.Dependencies[j] = c.Dependencies[j], c.Dependencies[i]
+	}
+	if err := SaveArchive(c, &second); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("Expected identical archives")
+	}
+
+	zr, err := gzip.NewReader(&first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	prev := ""
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hd.Name < prev {
+			t.Errorf("Expected %s to come before %s", hd.Name, prev)
+		}
+		prev = hd.Name
+	}
+}
+
+func TestSaveArchiveInOrder(t *testing.T) {
+	order := []string{
+		"ahab/templates/whale.yaml",
+		"ahab/values.yaml",
+		"ahab/Chart.yaml",
+		"ahab/README.md",
+		"ahab/charts/moby/Chart.yaml",
+	}
+	contents := map[string]string{
+		"ahab/Chart.yaml":             "name: ahab\nversion: 0.1.0\n",
+		"ahab/values.yaml":            "ship: Pequod\n",
+		"ahab/templates/whale.yaml":   "kind: Whale\n",
+		"ahab/README.md":              "# Ahab\n",
+		"ahab/charts/moby/Chart.yaml": "name: moby\nversion: 0.1.0\n",
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, n := range order {
+		if err := writeToTar(tw, n, []byte(contents[n])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	zw.Close()
+
+	var recorded []string
+	c, err := LoadArchive(&buf, WithEntryOrder(&recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recorded, order) {
+		t.Fatalf("Expected entry order %v, got %v", order, recorded)
+	}
+
+	var out bytes.Buffer
+	if err := SaveArchiveInOrder(c, &out, recorded); err != nil {
+		t.Fatal(err)
+	}
+	var resaved []string
+	if _, err := LoadArchive(&out, WithEntryOrder(&resaved)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resaved, order) {
+		t.Errorf("Expected re-saved entry order %v, got %v", order, resaved)
+	}
+}
+
+func BenchmarkSaveArchive(b *testing.B) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab", Version: "0.1.0"},
+		Values:   &chart.Config{Raw: "ship: Pequod"},
+	}
+	data := bytes.Repeat([]byte("kind: Whale\n"), 100)
+	// Add 100 files in reverse order, so that they all need sorting.
+	for i := 99; i >= 0; i-- {
+		c.Templates = append(c.Templates, &chart.Template{Name: fmt.Sprintf("templates/whale-%03d.yaml", i), Data: data})
+	}
+
+	// The baseline writes the same entries unsorted, so that the cost of
+	// sorting them is the difference between the two.
+	b.Run("unsorted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			entries, err := tarEntries(c, "")
+			if err != nil {
+				b.Fatal(err)
+			}
+			a := NewArchiveWriter(ioutil.Discard, c.Metadata.Name)
+			for _, e := range entries {
+				if err := writeToTar(a.twriter, e.name, e.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := a.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("sorted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := SaveArchive(c, ioutil.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
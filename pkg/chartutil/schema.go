@@ -0,0 +1,852 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// SchemafileName is the name of the JSON Schema for a chart's values.
+const SchemafileName = "values.schema.json"
+
+// GenerateStrategy chooses the example values that GenerateValues produces.
+type GenerateStrategy interface {
+	// Value returns a value for a schema of the given type: "string",
+	// "integer", "number", or "boolean". The whole schema is passed so that
+	// keywords such as format, enum, and minimum can be honored.
+	Value(typ string, schema map[string]interface{}) interface{}
+}
+
+// DefaultStrategy is a GenerateStrategy that always gives the same, simple
+// values: an empty string, 1, or false, unless the schema asks for something
+// else with enum, minimum, minLength, or a well-known format such as hostname.
+type DefaultStrategy struct{}
+
+// formatExamples are the example values for well-known string formats.
+var formatExamples = map[string]string{
+	"date":      "2016-01-01",
+	"date-time": "2016-01-01T00:00:00Z",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "127.0.0.1",
+	"ipv6":      "::1",
+	"uri":       "https://example.com",
+}
+
+// Value implements GenerateStrategy.
+func (DefaultStrategy) Value(typ string, schema map[string]interface{}) interface{} {
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	switch typ {
+	case "string":
+		if ex, ok := formatExamples[schemaString(schema, "format")]; ok {
+			return ex
+		}
+		n, _ := schemaNumber(schema, "minLength")
+		return strings.Repeat("a", int(n))
+	case "integer", "number":
+		if min, ok := schemaNumber(schema, "minimum"); ok && min > 1 {
+			return min
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && max < 1 {
+			return max
+		}
+		return float64(1)
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// RandomStrategy is a GenerateStrategy that gives varied values, for
+// fuzz-style testing. The values still honor enum, format, and ranges.
+type RandomStrategy struct {
+	rand *rand.Rand
+}
+
+// NewRandomStrategy returns a RandomStrategy. The same seed always gives the
+// same values, so that failures can be reproduced.
+func NewRandomStrategy(seed int64) *RandomStrategy {
+	return &RandomStrategy{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Value implements GenerateStrategy.
+func (s *RandomStrategy) Value(typ string, schema map[string]interface{}) interface{} {
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[s.rand.Intn(len(enum))]
+	}
+	switch typ {
+	case "string":
+		word := s.word(schema)
+		switch schemaString(schema, "format") {
+		case "hostname":
+			return word + ".example.com"
+		case "email":
+			return word + "@example.com"
+		case "uri":
+			return "https://" + word + ".example.com"
+		case "":
+			return word
+		default:
+			return DefaultStrategy{}.Value(typ, schema)
+		}
+	case "integer", "number":
+		min, hasMin := schemaNumber(schema, "minimum")
+		max, hasMax := schemaNumber(schema, "maximum")
+		if !hasMin {
+			min = -1000
+		}
+		if !hasMax || max < min {
+			max = min + 2000
+		}
+		if typ == "integer" {
+			return min + float64(s.rand.Int63n(int64(max-min)+1))
+		}
+		return min + s.rand.Float64()*(max-min)
+	case "boolean":
+		return s.rand.Intn(2) == 1
+	}
+	return nil
+}
+
+// word returns a random lowercase word of a length allowed by the schema.
+func (s *RandomStrategy) word(schema map[string]interface{}) string {
+	min, _ := schemaNumber(schema, "minLength")
+	max, ok := schemaNumber(schema, "maxLength")
+	if !ok || max < min {
+		max = min + 12
+	}
+	n := int(min) + s.rand.Intn(int(max-min)+1)
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('a' + s.rand.Intn(26))
+	}
+	return string(b)
+}
+
+// GenerateValues produces example values for a chart from its
+// values.schema.json.
+//
+// Every property of every object in the schema is filled in, and arrays are
+// given a single item (or minItems items). Where a schema gives a default,
+// the default is used. The choice of scalar values is left to strategy. Only
+// a subset of JSON Schema is understood: the first branch of oneOf and anyOf
+// is used, the branches of allOf are merged, and $ref is not followed.
+func GenerateValues(c *chart.Chart, strategy GenerateStrategy) (map[string]interface{}, error) {
+	data, ok := GetFile(c, SchemafileName)
+	if !ok {
+		return nil, fmt.Errorf("chart has no %s", SchemafileName)
+	}
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %s", SchemafileName, err)
+	}
+
+	vals, ok := generateValue(schema, strategy).(map[string]interface{})
+	if !ok {
+		return nil, errors.New(SchemafileName + " does not describe an object")
+	}
+	return vals, nil
+}
+
+// generateValue produces a value for a single schema.
+func generateValue(schema map[string]interface{}, strategy GenerateStrategy) interface{} {
+	if def, ok := schema["default"]; ok {
+		return copyValue(def)
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if branches, ok := schema[key].([]interface{}); ok && len(branches) > 0 {
+			if b, ok := branches[0].(map[string]interface{}); ok {
+				return generateValue(b, strategy)
+			}
+		}
+	}
+	if branches, ok := schema["allOf"].([]interface{}); ok {
+		merged := map[string]interface{}{}
+		for _, b := range branches {
+			if bs, ok := b.(map[string]interface{}); ok {
+				if m, ok := generateValue(bs, strategy).(map[string]interface{}); ok {
+					coalesceTables(merged, m)
+				}
+			}
+		}
+		return merged
+	}
+
+	switch typ := schemaType(schema); typ {
+	case "object":
+		obj := map[string]interface{}{}
+		props, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		// Walk the properties in order, so that RandomStrategy is repeatable.
+		sort.Strings(names)
+		for _, name := range names {
+			if ps, ok := props[name].(map[string]interface{}); ok {
+				obj[name] = generateValue(ps, strategy)
+			}
+		}
+		return obj
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		n, ok := schemaNumber(schema, "minItems")
+		if !ok {
+			n = 1
+		}
+		list := []interface{}{}
+		for i := 0; i < int(n) && items != nil; i++ {
+			list = append(list, generateValue(items, strategy))
+		}
+		return list
+	case "null":
+		return nil
+	default:
+		return strategy.Value(typ, schema)
+	}
+}
+
+// schemaType returns the type of a schema. For a list of types, the first
+// type other than null is used. A schema with properties is an object.
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+		return "null"
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// schemaString returns a string keyword of a schema, or "".
+func schemaString(schema map[string]interface{}, key string) string {
+	s, _ := schema[key].(string)
+	return s
+}
+
+// schemaNumber returns a numeric keyword of a schema, and whether it is set.
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	n, ok := schema[key].(float64)
+	return n, ok
+}
+
+// ValidationError is a problem found in a chart's values by a SchemaValidator.
+type ValidationError struct {
+	// Validator is the name the validator was registered under.
+	Validator string
+	// Path is the dot-separated path of the offending value, such as
+	// "image.tag". It is empty for problems with the values as a whole.
+	Path string
+	// Message describes the problem.
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", e.Validator, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Validator, e.Path, e.Message)
+}
+
+// SchemaValidator checks the values to be used with a chart.
+//
+// Implementations can apply any policy, such as a JSON Schema, OPA, or CUE.
+type SchemaValidator interface {
+	Validate(c *chart.Chart, vals map[string]interface{}) []ValidationError
+}
+
+var (
+	schemaValidatorsMu sync.RWMutex
+	schemaValidators   = map[string]SchemaValidator{"jsonschema": jsonSchemaValidator{}}
+)
+
+// RegisterSchemaValidator makes a SchemaValidator available to ValidateWithAll
+// under the given name. Registering a name again replaces the validator; the
+// built-in JSON Schema validator is registered as "jsonschema".
+func RegisterSchemaValidator(name string, v SchemaValidator) {
+	schemaValidatorsMu.Lock()
+	defer schemaValidatorsMu.Unlock()
+	schemaValidators[name] = v
+}
+
+// ValidateWithAll checks vals against every registered SchemaValidator, in
+// order of name, and returns all of their findings.
+func ValidateWithAll(c *chart.Chart, vals map[string]interface{}) []ValidationError {
+	schemaValidatorsMu.RLock()
+	names := make([]string, 0, len(schemaValidators))
+	for name := range schemaValidators {
+		names = append(names, name)
+	}
+	validators := make([]SchemaValidator, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		validators[i] = schemaValidators[name]
+	}
+	schemaValidatorsMu.RUnlock()
+
+	errs := []ValidationError{}
+	for i, v := range validators {
+		for _, e := range v.Validate(c, vals) {
+			if e.Validator == "" {
+				e.Validator = names[i]
+			}
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// jsonSchemaValidator checks values against the chart's values.schema.json.
+//
+// It understands the validation keywords of JSON Schema draft 7, including
+// allOf, anyOf, oneOf, and not. A $ref may point anywhere within the schema,
+// such as "#/definitions/port", but not to another document. The few
+// keywords it does not implement, such as if and dependencies, are reported
+// as errors rather than ignored. Charts without a schema always pass.
+type jsonSchemaValidator struct{}
+
+func (jsonSchemaValidator) Validate(c *chart.Chart, vals map[string]interface{}) []ValidationError {
+	data, ok := GetFile(c, SchemafileName)
+	if !ok {
+		return nil
+	}
+	schema := map[string]interface{}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return []ValidationError{{Message: fmt.Sprintf("cannot parse %s: %s", SchemafileName, err)}}
+	}
+	if errs := unsupportedKeywords(schema); len(errs) > 0 {
+		return errs
+	}
+	// Round-trip the values through JSON, so that numbers are float64 and
+	// Values tables are plain maps, as they are in the schema.
+	b, err := json.Marshal(vals)
+	if err != nil {
+		return []ValidationError{{Message: err.Error()}}
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return []ValidationError{{Message: err.Error()}}
+	}
+	return newSchemaChecker(schema).check(schema, v, "")
+}
+
+// unsupportedSchemaKeywords are the JSON Schema keywords that
+// jsonSchemaValidator does not implement.
+var unsupportedSchemaKeywords = []string{
+	"$dynamicRef", "$recursiveRef", "contains", "dependencies",
+	"dependentRequired", "dependentSchemas", "else", "if", "maxContains",
+	"minContains", "prefixItems", "then", "unevaluatedItems",
+	"unevaluatedProperties",
+}
+
+// unsupportedKeywords reports each use of an unsupported keyword in schema
+// or its subschemas, so that a schema is never silently half applied.
+func unsupportedKeywords(schema map[string]interface{}) []ValidationError {
+	var errs []ValidationError
+	walkSchema(schema, "", func(s map[string]interface{}, path string) {
+		for _, key := range unsupportedSchemaKeywords {
+			if _, ok := s[key]; !ok {
+				continue
+			}
+			if path != "" {
+				key = path + "." + key
+			}
+			errs = append(errs, ValidationError{Message: fmt.Sprintf("%s: %s: keyword is not supported", SchemafileName, key)})
+		}
+	})
+	return errs
+}
+
+// walkSchema calls fn for schema and each of its subschemas, along with the
+// dot-separated path of the keyword that holds it. Subschemas that are
+// booleans are skipped.
+func walkSchema(v interface{}, path string, fn func(schema map[string]interface{}, path string)) {
+	schema, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	fn(schema, path)
+
+	at := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	for _, key := range []string{"definitions", "properties", "patternProperties"} {
+		subs, _ := schema[key].(map[string]interface{})
+		names := make([]string, 0, len(subs))
+		for name := range subs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			walkSchema(subs[name], at(key+"."+name), fn)
+		}
+	}
+	for _, key := range []string{"items", "additionalItems", "additionalProperties", "propertyNames", "contains", "not", "if", "then", "else"} {
+		walkSchema(schema[key], at(key), fn)
+	}
+	for _, key := range []string{"items", "allOf", "anyOf", "oneOf"} {
+		list, _ := schema[key].([]interface{})
+		for i, sub := range list {
+			walkSchema(sub, fmt.Sprintf("%s[%d]", at(key), i), fn)
+		}
+	}
+}
+
+// schemaChecker validates values against a schema document.
+type schemaChecker struct {
+	// root is the whole document, against which $refs are resolved.
+	root interface{}
+	// following holds the $refs being followed at each value path, so that
+	// a cycle of references that never reaches a nested value is caught.
+	following map[string]bool
+}
+
+func newSchemaChecker(root interface{}) *schemaChecker {
+	return &schemaChecker{root: root, following: map[string]bool{}}
+}
+
+// check validates v against a schema, reporting problems under path. As in
+// draft 6 and later, the schema may be an object or the boolean true or false.
+func (sc *schemaChecker) check(s interface{}, v interface{}, path string) []ValidationError {
+	fail := func(format string, args ...interface{}) []ValidationError {
+		return []ValidationError{{Path: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	schema, ok := s.(map[string]interface{})
+	if !ok {
+		if s == false {
+			return fail("no value is allowed")
+		}
+		return nil
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		key := path + "\x00" + ref
+		if sc.following[key] {
+			return fail("$ref %q refers to itself", ref)
+		}
+		target, err := sc.resolve(ref)
+		if err != nil {
+			return fail("%s", err)
+		}
+		sc.following[key] = true
+		defer delete(sc.following, key)
+		// As in draft 7, the keywords beside a $ref are ignored.
+		return sc.check(target, v, path)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if equalValues(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %v", enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && !equalValues(c, v) {
+		return fail("must be %v", c)
+	}
+	if t, ok := schema["type"]; ok && !typeMatches(t, schemaType(schema), v) {
+		return fail("must be of type %v", schema["type"])
+	}
+
+	var errs []ValidationError
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			errs = append(errs, sc.check(sub, v, path)...)
+		}
+	}
+	if branches, ok := schema["anyOf"].([]interface{}); ok {
+		if n, near := sc.match(branches, v, path); n == 0 {
+			if near == nil {
+				near = fail("must match at least one schema in anyOf")
+			}
+			errs = append(errs, near...)
+		}
+	}
+	if branches, ok := schema["oneOf"].([]interface{}); ok {
+		switch n, near := sc.match(branches, v, path); {
+		case n == 0 && near != nil:
+			errs = append(errs, near...)
+		case n == 0:
+			errs = append(errs, fail("must match exactly one schema in oneOf, but matches none")...)
+		case n > 1:
+			errs = append(errs, fail("must match exactly one schema in oneOf, but matches %d", n)...)
+		}
+	}
+	if not, ok := schema["not"]; ok && len(sc.check(not, v, path)) == 0 {
+		errs = append(errs, fail("must not match the schema in not")...)
+	}
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if n, ok := schemaNumber(schema, "minProperties"); ok && float64(len(vv)) < n {
+			errs = append(errs, fail("must have at least %v properties", n)...)
+		}
+		if n, ok := schemaNumber(schema, "maxProperties"); ok && float64(len(vv)) > n {
+			errs = append(errs, fail("must have at most %v properties", n)...)
+		}
+		if req, ok := schema["required"].([]interface{}); ok {
+			for _, r := range req {
+				if name, ok := r.(string); ok {
+					if _, ok := vv[name]; !ok {
+						errs = append(errs, fail("missing required property %q", name)...)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		patternProps, _ := schema["patternProperties"].(map[string]interface{})
+		patterns := make([]string, 0, len(patternProps))
+		for pattern := range patternProps {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		names := make([]string, 0, len(vv))
+		for name := range vv {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := name
+			if path != "" {
+				p = path + "." + name
+			}
+			if pn, ok := schema["propertyNames"]; ok {
+				errs = append(errs, sc.check(pn, name, p)...)
+			}
+			matched := false
+			if ps, ok := props[name]; ok {
+				errs = append(errs, sc.check(ps, vv[name], p)...)
+				matched = true
+			}
+			for _, pattern := range patterns {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					errs = append(errs, fail("invalid pattern %q in schema", pattern)...)
+					continue
+				}
+				if re.MatchString(name) {
+					errs = append(errs, sc.check(patternProps[pattern], vv[name], p)...)
+					matched = true
+				}
+			}
+			if extra, ok := schema["additionalProperties"]; ok && !matched {
+				if extra == false {
+					errs = append(errs, ValidationError{Path: p, Message: "additional property not allowed"})
+				} else {
+					errs = append(errs, sc.check(extra, vv[name], p)...)
+				}
+			}
+		}
+	case []interface{}:
+		if n, ok := schemaNumber(schema, "minItems"); ok && float64(len(vv)) < n {
+			errs = append(errs, fail("must have at least %v items", n)...)
+		}
+		if n, ok := schemaNumber(schema, "maxItems"); ok && float64(len(vv)) > n {
+			errs = append(errs, fail("must have at most %v items", n)...)
+		}
+		if schema["uniqueItems"] == true {
+		unique:
+			for i := range vv {
+				for j := i + 1; j < len(vv); j++ {
+					if equalValues(vv[i], vv[j]) {
+						errs = append(errs, fail("must not contain duplicate items")...)
+						break unique
+					}
+				}
+			}
+		}
+		for i, item := range vv {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch items := schema["items"].(type) {
+			case nil:
+			case []interface{}:
+				if i < len(items) {
+					errs = append(errs, sc.check(items[i], item, p)...)
+				} else if extra, ok := schema["additionalItems"]; ok {
+					errs = append(errs, sc.check(extra, item, p)...)
+				}
+			default:
+				errs = append(errs, sc.check(items, item, p)...)
+			}
+		}
+	case string:
+		n := float64(len([]rune(vv)))
+		if min, ok := schemaNumber(schema, "minLength"); ok && n < min {
+			errs = append(errs, fail("must be at least %v characters", min)...)
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && n > max {
+			errs = append(errs, fail("must be at most %v characters", max)...)
+		}
+		if pattern := schemaString(schema, "pattern"); pattern != "" {
+			if re, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fail("invalid pattern %q in schema", pattern)...)
+			} else if !re.MatchString(vv) {
+				errs = append(errs, fail("must match %q", pattern)...)
+			}
+		}
+	case float64:
+		// Draft 4 made minimum and maximum exclusive with a boolean;
+		// later drafts give exclusiveMinimum and exclusiveMaximum a limit.
+		if min, ok := schemaNumber(schema, "minimum"); ok {
+			if schema["exclusiveMinimum"] == true && vv <= min {
+				errs = append(errs, fail("must be greater than %v", min)...)
+			} else if vv < min {
+				errs = append(errs, fail("must be at least %v", min)...)
+			}
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok {
+			if schema["exclusiveMaximum"] == true && vv >= max {
+				errs = append(errs, fail("must be less than %v", max)...)
+			} else if vv > max {
+				errs = append(errs, fail("must be at most %v", max)...)
+			}
+		}
+		if min, ok := schemaNumber(schema, "exclusiveMinimum"); ok && vv <= min {
+			errs = append(errs, fail("must be greater than %v", min)...)
+		}
+		if max, ok := schemaNumber(schema, "exclusiveMaximum"); ok && vv >= max {
+			errs = append(errs, fail("must be less than %v", max)...)
+		}
+		if m, ok := schemaNumber(schema, "multipleOf"); ok && m > 0 {
+			if q := vv / m; math.Abs(q-math.Floor(q+0.5)) > 1e-9 {
+				errs = append(errs, fail("must be a multiple of %v", m)...)
+			}
+		}
+	}
+	return errs
+}
+
+// match checks v against each of the schemas of an anyOf or oneOf, and
+// returns how many of them v matches. If it matches none, but exactly one
+// schema accepted v itself and failed only on nested values, the problems
+// with that schema are returned too, as it is most likely the one meant.
+func (sc *schemaChecker) match(branches []interface{}, v interface{}, path string) (int, []ValidationError) {
+	matched := 0
+	var near [][]ValidationError
+	for _, b := range branches {
+		errs := sc.check(b, v, path)
+		if len(errs) == 0 {
+			matched++
+			continue
+		}
+		nested := true
+		for _, e := range errs {
+			if e.Path == path {
+				nested = false
+				break
+			}
+		}
+		if nested {
+			near = append(near, errs)
+		}
+	}
+	if matched == 0 && len(near) == 1 {
+		return 0, near[0]
+	}
+	return matched, nil
+}
+
+// resolve returns the part of the schema document that a $ref points to.
+// Only JSON pointers within the document, such as "#/definitions/port", are
+// supported.
+func (sc *schemaChecker) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("cannot resolve $ref %q: only references within the schema are supported", ref)
+	}
+	node := sc.root
+	ptr := ref[1:]
+	if ptr == "" {
+		return node, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("cannot resolve $ref %q: not a JSON pointer", ref)
+	}
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+		found := false
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node, found = n[tok]
+		case []interface{}:
+			if i, err := strconv.Atoi(tok); err == nil && i >= 0 && i < len(n) {
+				node, found = n[i], true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("cannot resolve $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// typeMatches reports whether v is of one of the types allowed by the schema's
+// type keyword. typ is the primary type, as returned by schemaType.
+func typeMatches(types interface{}, typ string, v interface{}) bool {
+	allowed := []string{typ}
+	if list, ok := types.([]interface{}); ok {
+		allowed = allowed[:0]
+		for _, t := range list {
+			if s, ok := t.(string); ok {
+				allowed = append(allowed, s)
+			}
+		}
+	}
+	for _, t := range allowed {
+		switch t {
+		case "object":
+			if _, ok := v.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(float64); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := v.(float64); ok && n == float64(int64(n)) {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "null":
+			if v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExtractSchema returns the contents of a chart's values.schema.json, and
+// whether the chart has one. An error is returned if the schema is not valid
+// JSON; use ValidateSchemaItself to check that it is a usable schema.
+func ExtractSchema(c *chart.Chart) ([]byte, bool, error) {
+	data, ok := GetFile(c, SchemafileName)
+	if !ok {
+		return nil, false, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data, true, fmt.Errorf("cannot parse %s: %s", SchemafileName, err)
+	}
+	return data, true, nil
+}
+
+// ValidateSchemaItself checks that schema is a valid JSON Schema, so that
+// authoring mistakes are found before the schema is used to validate values.
+//
+// The schema is validated against the JSON Schema draft 7 meta-schema. It
+// is also checked for mistakes the meta-schema cannot express: patterns must
+// compile, and limits such as minLength and maxLength must not contradict
+// each other. All problems are reported in a single error.
+func ValidateSchemaItself(schema []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(schema, &v); err != nil {
+		return fmt.Errorf("cannot parse schema: %s", err)
+	}
+	var problems []string
+	for _, e := range newSchemaChecker(draft07MetaSchema).check(draft07MetaSchema, v, "") {
+		if e.Path == "" {
+			problems = append(problems, e.Message)
+		} else {
+			problems = append(problems, e.Path+": "+e.Message)
+		}
+	}
+	walkSchema(v, "", func(s map[string]interface{}, path string) {
+		problems = append(problems, checkSchemaLimits(s, path)...)
+	})
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid schema: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkSchemaLimits returns the problems with the patterns and limits of a
+// single schema, each prefixed by the path of the offending keyword.
+func checkSchemaLimits(schema map[string]interface{}, path string) []string {
+	at := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	var problems []string
+	if s, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(s); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid pattern %q", at("pattern"), s))
+		}
+	}
+	if props, ok := schema["patternProperties"].(map[string]interface{}); ok {
+		patterns := make([]string, 0, len(props))
+		for pattern := range props {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, s := range patterns {
+			if _, err := regexp.Compile(s); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid pattern %q", at("patternProperties"), s))
+			}
+		}
+	}
+	for _, pair := range [][2]string{{"minLength", "maxLength"}, {"minItems", "maxItems"}, {"minProperties", "maxProperties"}, {"minimum", "maximum"}} {
+		min, hasMin := schemaNumber(schema, pair[0])
+		max, hasMax := schemaNumber(schema, pair[1])
+		if hasMin && hasMax && min > max {
+			problems = append(problems, fmt.Sprintf("%s: is greater than %s", at(pair[0]), pair[1]))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const testSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "host": {"type": "string", "format": "hostname"},
+    "replicas": {"type": "integer", "minimum": 1, "maximum": 5},
+    "ratio": {"type": "number"},
+    "enabled": {"type": "boolean"},
+    "pullPolicy": {"type": "string", "enum": ["IfNotPresent", "Always"]},
+    "port": {"type": "integer", "default": 8080},
+    "tags": {"type": "array", "items": {"type": "string", "minLength": 3}},
+    "annotations": {"type": ["null", "object"], "properties": {"owner": {"type": "string", "format": "email"}}}
+  }
+}`
+
+func schemaChart(schema string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "schematic"},
+		Files:    []*any.Any{{TypeUrl: SchemafileName, Value: []byte(schema)}},
+	}
+}
+
+func TestGenerateValues(t *testing.T) {
+	vals, err := GenerateValues(schemaChart(testSchema), DefaultStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]interface{}{
+		"name":        "",
+		"host":        "example.com",
+		"replicas":    float64(1),
+		"ratio":       float64(1),
+		"enabled":     false,
+		"pullPolicy":  "IfNotPresent",
+		"port":        float64(8080),
+		"tags":        []interface{}{"aaa"},
+		"annotations": map[string]interface{}{"owner": "user@example.com"},
+	}
+	if !reflect.DeepEqual(vals, expect) {
+		t.Errorf("Expected %v, got %v", expect, vals)
+	}
+
+	if _, err := GenerateValues(&chart.Chart{Metadata: &chart.Metadata{Name: "plain"}}, DefaultStrategy{}); err == nil {
+		t.Error("Expected an error for a chart with no schema")
+	}
+	if _, err := GenerateValues(schemaChart(`{"type": "string"}`), DefaultStrategy{}); err == nil {
+		t.Error("Expected an error for a schema that is not an object")
+	}
+}
+
+func TestGenerateValuesRandom(t *testing.T) {
+	c := schemaChart(testSchema)
+	first, err := GenerateValues(c, NewRandomStrategy(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := GenerateValues(c, NewRandomStrategy(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("Expected the same seed to give the same values, got %v and %v", first, again)
+	}
+
+	seen := map[interface{}]bool{}
+	for seed := int64(0); seed < 20; seed++ {
+		vals, err := GenerateValues(c, NewRandomStrategy(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := vals["replicas"].(float64)
+		if r < 1 || r > 5 || r != float64(int(r)) {
+			t.Errorf("Expected an integer replicas in [1, 5], got %v", r)
+		}
+		if p := vals["pullPolicy"]; p != "IfNotPresent" && p != "Always" {
+			t.Errorf("Expected pullPolicy from the enum, got %v", p)
+		}
+		if tag := vals["tags"].([]interface{})[0].(string); len(tag) < 3 {
+			t.Errorf("Expected a tag of at least 3 characters, got %q", tag)
+		}
+		seen[vals["name"]] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Expected varied names, got %v", seen)
+	}
+}
+
+// countingValidator is a SchemaValidator that rejects a value named "forbidden".
+type countingValidator struct {
+	calls int
+}
+
+func (v *countingValidator) Validate(c *chart.Chart, vals map[string]interface{}) []ValidationError {
+	v.calls++
+	if _, ok := vals["forbidden"]; ok {
+		return []ValidationError{{Path: "forbidden", Message: "is not allowed by policy"}}
+	}
+	return nil
+}
+
+func TestValidateWithAll(t *testing.T) {
+	policy := &countingValidator{}
+	RegisterSchemaValidator("policy", policy)
+	defer func() {
+		schemaValidatorsMu.Lock()
+		delete(schemaValidators, "policy")
+		schemaValidatorsMu.Unlock()
+	}()
+
+	c := schemaChart(`{
+  "type": "object",
+  "required": ["name"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "pattern": "^[a-z]+$"},
+    "replicas": {"type": "integer", "minimum": 1},
+    "pullPolicy": {"enum": ["IfNotPresent", "Always"]},
+    "ports": {"type": "array", "maxItems": 2, "items": {"type": "integer"}},
+    "forbidden": {"type": "boolean"}
+  }
+}`)
+
+	good := map[string]interface{}{"name": "ahab", "replicas": 3, "ports": []interface{}{80, 443}}
+	if errs := ValidateWithAll(c, good); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+
+	bad := map[string]interface{}{
+		"replicas":   1.5,
+		"pullPolicy": "Never",
+		"ports":      []interface{}{80, "http"},
+		"forbidden":  true,
+		"whale":      "white",
+	}
+	expect := []string{
+		"jsonschema: missing required property \"name\"",
+		"jsonschema: ports[1]: must be of type integer",
+		"jsonschema: pullPolicy: must be one of [IfNotPresent Always]",
+		"jsonschema: replicas: must be of type integer",
+		"jsonschema: whale: additional property not allowed",
+		"policy: forbidden: is not allowed by policy",
+	}
+	errs := ValidateWithAll(c, bad)
+	if len(errs) != len(expect) {
+		t.Fatalf("Expected %d errors, got %v", len(expect), errs)
+	}
+	for i, e := range expect {
+		if errs[i].Error() != e {
+			t.Errorf("Expected %q, got %q", e, errs[i].Error())
+		}
+	}
+	if policy.calls != 2 {
+		t.Errorf("Expected the policy validator to be called twice, got %d", policy.calls)
+	}
+
+	// Without a schema, only the policy applies.
+	plain := &chart.Chart{Metadata: &chart.Metadata{Name: "plain"}}
+	if errs := ValidateWithAll(plain, bad); len(errs) != 1 || errs[0].Validator != "policy" {
+		t.Errorf("Expected only the policy error, got %v", errs)
+	}
+}
+
+func TestValidateSchemaComposition(t *testing.T) {
+	c := schemaChart(`{
+  "definitions": {
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535},
+    "node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/node"}, "id": {"type": "string"}}}
+  },
+  "type": "object",
+  "properties": {
+    "port": {"$ref": "#/definitions/port"},
+    "list": {"$ref": "#/definitions/node"},
+    "size": {"allOf": [{"type": "integer"}, {"minimum": 1}]},
+    "target": {"anyOf": [{"type": "string", "pattern": "^[a-z]+$"}, {"type": "object", "properties": {"host": {"type": "string"}}}]},
+    "mode": {"oneOf": [{"type": "string"}, {"enum": ["auto", 0]}]},
+    "name": {"not": {"enum": ["default"]}},
+    "tags": {"type": "array", "uniqueItems": true}
+  }
+}`)
+
+	good := map[string]interface{}{
+		"port":   8080,
+		"list":   map[string]interface{}{"id": "a", "next": map[string]interface{}{"id": "b"}},
+		"size":   2,
+		"target": "web",
+		"mode":   0,
+		"name":   "ahab",
+		"tags":   []interface{}{"a", "b"},
+	}
+	if errs := ValidateWithAll(c, good); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+
+	bad := map[string]interface{}{
+		"port":   0,
+		"list":   map[string]interface{}{"next": map[string]interface{}{"id": 1}},
+		"size":   0,
+		"target": map[string]interface{}{"host": 80},
+		"mode":   "auto",
+		"name":   "default",
+		"tags":   []interface{}{"a", "a"},
+	}
+	expect := []string{
+		"jsonschema: list.next.id: must be of type string",
+		"jsonschema: mode: must match exactly one schema in oneOf, but matches 2",
+		"jsonschema: name: must not match the schema in not",
+		"jsonschema: port: must be at least 1",
+		"jsonschema: size: must be at least 1",
+		"jsonschema: tags: must not contain duplicate items",
+		"jsonschema: target.host: must be of type string",
+	}
+	errs := ValidateWithAll(c, bad)
+	if len(errs) != len(expect) {
+		t.Fatalf("Expected %d errors, got %v", len(expect), errs)
+	}
+	for i, e := range expect {
+		if errs[i].Error() != e {
+			t.Errorf("Expected %q, got %q", e, errs[i].Error())
+		}
+	}
+
+	for schema, want := range map[string]string{
+		`{"$ref": "#"}`:                                        `$ref "#" refers to itself`,
+		`{"$ref": "#/definitions/missing"}`:                    `cannot resolve $ref "#/definitions/missing"`,
+		`{"$ref": "other.json#"}`:                              "only references within the schema are supported",
+		`{"properties": {"a": {"if": {"type": "string"}}}}`:    "values.schema.json: properties.a.if: keyword is not supported",
+		`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`: "must match at least one schema in anyOf",
+	} {
+		errs := ValidateWithAll(schemaChart(schema), map[string]interface{}{"a": true})
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), want) {
+			t.Errorf("Expected an error containing %q for %s, got %v", want, schema, errs)
+		}
+	}
+}
+
+func TestExtractSchema(t *testing.T) {
+	schema := `{"type": "object"}`
+	data, ok, err := ExtractSchema(schemaChart(schema))
+	if err != nil || !ok || string(data) != schema {
+		t.Errorf("Expected %q, got %q, %t, %v", schema, data, ok, err)
+	}
+
+	if _, ok, err := ExtractSchema(schemaChart("{not json")); !ok || err == nil {
+		t.Errorf("Expected a parse error for a present schema, got %t, %v", ok, err)
+	}
+
+	plain := &chart.Chart{Metadata: &chart.Metadata{Name: "plain"}}
+	if data, ok, err := ExtractSchema(plain); ok || data != nil || err != nil {
+		t.Errorf("Expected no schema, got %q, %t, %v", data, ok, err)
+	}
+}
+
+func TestValidateSchemaItself(t *testing.T) {
+	good := `{
+  "type": "object",
+  "required": ["name"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": ["string", "null"], "pattern": "^[a-z]+$", "maxLength": 63},
+    "ports": {"type": "array", "minItems": 1, "items": {"type": "integer", "minimum": 1, "maximum": 65535}},
+    "mode": {"oneOf": [{"enum": ["a", "b"]}, true]}
+  }
+}`
+	if err := ValidateSchemaItself([]byte(good)); err != nil {
+		t.Errorf("Expected a valid schema, got %s", err)
+	}
+
+	bad := `{
+  "type": "objet",
+  "required": "name",
+  "properties": {
+    "name": {"type": "string", "pattern": "([a-z]+", "minLength": 5, "maxLength": 2},
+    "ports": {"items": {"minimum": "one"}},
+    "mode": {"oneOf": []}
+  }
+}`
+	err := ValidateSchemaItself([]byte(bad))
+	if err == nil {
+		t.Fatal("Expected an invalid schema")
+	}
+	for _, want := range []string{
+		"type: must match at least one schema in anyOf",
+		"required: must be of type array",
+		`properties.name.pattern: invalid pattern "([a-z]+"`,
+		"properties.name.minLength: is greater than maxLength",
+		"properties.ports.items.minimum: must be of type number",
+		"properties.mode.oneOf: must have at least 1 items",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %s", want, err)
+		}
+	}
+
+	if err := ValidateSchemaItself([]byte(`[]`)); err == nil {
+		t.Error("Expected an error for a schema that is not an object")
+	}
+
+	// Both the test schema and the meta-schema itself are valid.
+	for _, schema := range []string{testSchema, draft07MetaSchemaJSON} {
+		if err := ValidateSchemaItself([]byte(schema)); err != nil {
+			t.Errorf("Expected a valid schema, got %s", err)
+		}
+	}
+}
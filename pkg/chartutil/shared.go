@@ -0,0 +1,67 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/sync/singleflight"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// SharedLoader loads charts, sharing the work between concurrent loads of the
+// same chart.
+//
+// If several goroutines load the same path at once, the chart is read only
+// once, and each caller gets its own copy of the result. Loads that start
+// after the first one has finished read the chart again; SharedLoader does
+// not cache charts. It is safe for concurrent use.
+type SharedLoader struct {
+	opts  []LoadOption
+	group singleflight.Group
+	// load reads a chart; it is replaced in tests.
+	load func(name string, opts ...LoadOption) (*chart.Chart, error)
+}
+
+// NewSharedLoader returns a SharedLoader that loads charts with opts.
+func NewSharedLoader(opts ...LoadOption) *SharedLoader {
+	return &SharedLoader{opts: opts, load: LoadWithOptions}
+}
+
+// Load loads the chart at name, as Load does.
+//
+// Concurrent loads are matched by absolute path, so "./mychart" and
+// "mychart" are loaded once.
+func (l *SharedLoader) Load(name string) (*chart.Chart, error) {
+	key, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err, shared := l.group.Do(key, func() (interface{}, error) {
+		return l.load(name, l.opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := v.(*chart.Chart)
+	if shared {
+		c = proto.Clone(c).(*chart.Chart)
+	}
+	return c, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestSharedLoader(t *testing.T) {
+	l := NewSharedLoader()
+	var loads int32
+	release := make(chan struct{})
+	l.load = func(name string, opts ...LoadOption) (*chart.Chart, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return LoadWithOptions(name, opts...)
+	}
+
+	const n = 10
+	charts := make([]*chart.Chart, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := l.Load("testdata/frobnitz")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			charts[i] = c
+		}(i)
+	}
+	// Give every goroutine time to join the first load.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&loads); got != 1 {
+		t.Errorf("Expected 1 load, got %d", got)
+	}
+	for _, c := range charts {
+		if c == nil {
+			t.Fatal("Expected every caller to get a chart")
+		}
+		verifyFrobnitz(t, c)
+	}
+	if charts[0] == charts[1] {
+		t.Error("Expected each caller to get its own copy of the chart")
+	}
+
+	if _, err := l.Load("testdata/frobnitz"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&loads); got != 2 {
+		t.Errorf("Expected a later load to read the chart again, got %d loads", got)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// LargeFileThreshold is the size, in bytes, above which SizeReport warns about a file.
+const LargeFileThreshold = 1 << 20
+
+// ChartSizeReport describes how much space each component of a chart takes.
+//
+// All sizes are uncompressed, in bytes.
+type ChartSizeReport struct {
+	// TotalBytes is the size of the whole chart, including its dependencies.
+	TotalBytes int64
+	// ChartfileBytes is the size of the serialized Chart.yaml.
+	ChartfileBytes int64
+	// TemplateBytes is the size of the templates.
+	TemplateBytes int64
+	// ValuesBytes is the size of values.yaml.
+	ValuesBytes int64
+	// FilesBytes is the size of all other files.
+	FilesBytes int64
+	// Dependencies holds a report for each subchart, keyed by chart name.
+	Dependencies map[string]ChartSizeReport
+	// Warnings lists files larger than LargeFileThreshold, which slow down
+	// processing by Tiller.
+	Warnings []string
+}
+
+// SizeReport computes the size of each component of a chart.
+//
+// The report is computed from the loaded chart, and does not touch the disk.
+func SizeReport(c *chart.Chart) ChartSizeReport {
+	r := ChartSizeReport{Dependencies: map[string]ChartSizeReport{}}
+	warn := func(name string, size int) {
+		if size > LargeFileThreshold {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s is %d bytes, which is larger than %d bytes", name, size, LargeFileThreshold))
+		}
+	}
+
+	if c.Metadata != nil {
+		// The original bytes are not kept, so this is the re-serialized size.
+		if b, err := yaml.Marshal(c.Metadata); err == nil {
+			r.ChartfileBytes = int64(len(b))
+		}
+	}
+	if c.Values != nil {
+		r.ValuesBytes = int64(len(c.Values.Raw))
+		warn(ValuesfileName, len(c.Values.Raw))
+	}
+	for _, t := range c.Templates {
+		r.TemplateBytes += int64(len(t.Data))
+		warn(t.Name, len(t.Data))
+	}
+	for _, f := range c.Files {
+		r.FilesBytes += int64(len(f.Value))
+		warn(f.TypeUrl, len(f.Value))
+	}
+	r.TotalBytes = r.ChartfileBytes + r.ValuesBytes + r.TemplateBytes + r.FilesBytes
+
+	for _, dep := range c.Dependencies {
+		dr := SizeReport(dep)
+		name := ""
+		if dep.Metadata != nil {
+			name = dep.Metadata.Name
+		}
+		r.Dependencies[name] = dr
+		r.TotalBytes += dr.TotalBytes
+		for _, w := range dr.Warnings {
+			r.Warnings = append(r.Warnings, ChartsDir+"/"+name+"/"+w)
+		}
+	}
+	return r
+}
+
+// Size returns the total uncompressed size of a chart and its dependencies.
+//
+// This includes the templates, values, Chart.yaml, and other files. It is
+// computed from the loaded chart, and does not touch the disk.
+func Size(c *chart.Chart) int64 {
+	return SizeReport(c).TotalBytes
+}
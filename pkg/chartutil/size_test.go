@@ -0,0 +1,85 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestSizeReport(t *testing.T) {
+	sub := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "sub", Version: "0.1.0"},
+		Files:    []*any.Any{{TypeUrl: "big.bin", Value: make([]byte, LargeFileThreshold+1)}},
+	}
+	c := &chart.Chart{
+		Metadata:     &chart.Metadata{Name: "top", Version: "0.1.0"},
+		Values:       &chart.Config{Raw: "a: b"},
+		Templates:    []*chart.Template{{Name: "templates/pod.yaml", Data: []byte("kind: Pod")}},
+		Files:        []*any.Any{{TypeUrl: "README.md", Value: []byte("# top")}},
+		Dependencies: []*chart.Chart{sub},
+	}
+
+	meta, err := yaml.Marshal(c.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subMeta, err := yaml.Marshal(sub.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := SizeReport(c)
+	if r.ChartfileBytes != int64(len(meta)) {
+		t.Errorf("Expected %d Chart.yaml bytes, got %d", len(meta), r.ChartfileBytes)
+	}
+	if r.ValuesBytes != 4 || r.TemplateBytes != 9 || r.FilesBytes != 5 {
+		t.Errorf("Unexpected component sizes: %+v", r)
+	}
+	subTotal := int64(len(subMeta) + LargeFileThreshold + 1)
+	if got := r.Dependencies["sub"].TotalBytes; got != subTotal {
+		t.Errorf("Expected subchart to be %d bytes, got %d", subTotal, got)
+	}
+	if expect := int64(len(meta)+18) + subTotal; r.TotalBytes != expect {
+		t.Errorf("Expected %d total bytes, got %d", expect, r.TotalBytes)
+	}
+	if len(r.Warnings) != 1 || !strings.HasPrefix(r.Warnings[0], "charts/sub/big.bin") {
+		t.Errorf("Expected a warning about big.bin, got %v", r.Warnings)
+	}
+}
+
+func TestSize(t *testing.T) {
+	c := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "ahab"},
+		Values:    &chart.Config{Raw: "ship: Pequod"},
+		Templates: []*chart.Template{{Name: "templates/whale.yaml", Data: []byte("kind: Whale")}},
+		Files:     []*any.Any{{TypeUrl: "README.md", Value: []byte("Call me Ishmael.")}},
+		Dependencies: []*chart.Chart{
+			{Metadata: &chart.Metadata{Name: "starbuck"}},
+		},
+	}
+
+	// "name: ahab\n" is 11 bytes, and "name: starbuck\n" is 15 bytes.
+	if got := Size(c); got != 11+12+11+16+15 {
+		t.Errorf("Expected size %d, got %d", 11+12+11+16+15, got)
+	}
+}
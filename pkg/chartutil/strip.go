@@ -0,0 +1,133 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// StripDependencies returns a copy of c without its dependencies.
+//
+// Both the loaded Dependencies and any charts/ entries in Files are removed.
+// requirements.yaml and requirements.lock are kept, so that the dependencies
+// can be fetched again with 'helm dependency build'.
+func StripDependencies(c *chart.Chart) *chart.Chart {
+	nc := proto.Clone(c).(*chart.Chart)
+	nc.Dependencies = nil
+
+	files := []*any.Any{}
+	for _, f := range nc.Files {
+		if !strings.HasPrefix(f.TypeUrl, "charts/") {
+			files = append(files, f)
+		}
+	}
+	nc.Files = files
+	return nc
+}
+
+var (
+	// annotationsLine matches the start of an annotations map.
+	annotationsLine = regexp.MustCompile(`^(\s*)annotations:\s*$`)
+	// mapKeyLine matches a line that sets a key in a map, capturing the
+	// indentation and the key without quotes.
+	mapKeyLine = regexp.MustCompile(`^(\s*)["']?([^"':\s]+)["']?\s*:`)
+)
+
+// StripDevAnnotations returns a copy of c in which every annotation whose key
+// starts with one of prefixes, such as "ci/" or "dev/", has been removed from
+// the templates of c and its dependencies.
+//
+// Charts under development may carry annotations for CI or other tooling
+// that should not be released. Only keys in a block mapping under an
+// annotations: line are removed, so labels and other fields with the same
+// keys are kept. Templates are edited as text, since they cannot be parsed as
+// YAML before rendering; an annotation whose value spans several lines
+// should be written with a block scalar (| or >), whose lines are removed
+// with it.
+func StripDevAnnotations(c *chart.Chart, prefixes []string) (*chart.Chart, error) {
+	for _, p := range prefixes {
+		if p == "" {
+			return nil, errors.New("annotation prefix must not be empty")
+		}
+	}
+	nc := proto.Clone(c).(*chart.Chart)
+	stripAnnotations(nc, prefixes)
+	return nc, nil
+}
+
+// stripAnnotations removes the annotations starting with prefixes from the
+// templates of c and its dependencies.
+func stripAnnotations(c *chart.Chart, prefixes []string) {
+	for _, t := range c.Templates {
+		t.Data, _ = stripAnnotationLines(t.Data, func(key string) bool {
+			return hasAnyPrefix(key, prefixes)
+		})
+	}
+	for _, dep := range c.Dependencies {
+		stripAnnotations(dep, prefixes)
+	}
+}
+
+// stripAnnotationLines removes the annotations whose keys match from a single
+// template, and returns the template along with the keys that were removed.
+func stripAnnotationLines(data []byte, match func(key string) bool) ([]byte, []string) {
+	var removed []string
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	kept := lines[:0]
+	// block is the indentation of the current annotations: line, or -1 when
+	// outside of one. skip is the indentation of an annotation being removed,
+	// or -1.
+	block, skip := -1, -1
+	for _, line := range lines {
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		blank := len(bytes.TrimSpace(line)) == 0
+		if skip >= 0 && (blank || indent > skip) {
+			continue
+		}
+		skip = -1
+		if block >= 0 && !blank && indent <= block {
+			block = -1
+		}
+		if m := annotationsLine.FindSubmatch(line); m != nil {
+			block = len(m[1])
+		} else if m := mapKeyLine.FindSubmatch(line); block >= 0 && m != nil && match(string(m[2])) {
+			skip = len(m[1])
+			removed = append(removed, string(m[2]))
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return bytes.Join(kept, nil), removed
+}
+
+// hasAnyPrefix reports whether s starts with any of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
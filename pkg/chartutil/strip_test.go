@@ -0,0 +1,120 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestStripDependencies(t *testing.T) {
+	c, err := LoadWithOptions("testdata/frobnitz", WithFlatLoad())
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	full, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+
+	for _, ch := range []*chart.Chart{c, full} {
+		sc := StripDependencies(ch)
+		if len(sc.Dependencies) != 0 {
+			t.Errorf("Expected no dependencies, got %d", len(sc.Dependencies))
+		}
+		for _, f := range sc.Files {
+			if strings.HasPrefix(f.TypeUrl, "charts/") {
+				t.Errorf("Expected %s to be removed", f.TypeUrl)
+			}
+		}
+		if _, err := LoadRequirements(sc); err != nil {
+			t.Errorf("Expected requirements.yaml to be kept: %s", err)
+		}
+		if len(sc.Templates) != len(ch.Templates) {
+			t.Errorf("Expected %d templates, got %d", len(ch.Templates), len(sc.Templates))
+		}
+	}
+
+	if len(full.Dependencies) == 0 {
+		t.Error("Expected original chart to keep its dependencies")
+	}
+	if _, ok := GetFile(c, "charts/mariner-4.3.2.tgz"); !ok {
+		t.Error("Expected original chart to keep its charts/ files")
+	}
+}
+
+func TestStripDevAnnotations(t *testing.T) {
+	tpl := `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    dev/owner: ahab
+  annotations:
+    ci/skip-render: "true"
+    helm.sh/hook: pre-install
+    "dev/owner": ahab
+    dev/notes: |
+      Call me Ishmael.
+      Some years ago.
+    helm.sh/hook-weight: "5"
+spec:
+  containers: []
+`
+	expect := `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Release.Name }}
+  labels:
+    dev/owner: ahab
+  annotations:
+    helm.sh/hook: pre-install
+    helm.sh/hook-weight: "5"
+spec:
+  containers: []
+`
+	c := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "ahab"},
+		Templates: []*chart.Template{{Name: "templates/pod.yaml", Data: []byte(tpl)}},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata:  &chart.Metadata{Name: "whale"},
+				Templates: []*chart.Template{{Name: "templates/pod.yaml", Data: []byte(tpl)}},
+			},
+		},
+	}
+
+	nc, err := StripDevAnnotations(c, []string{"ci/", "dev/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(nc.Templates[0].Data); got != expect {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expect, got)
+	}
+	if got := string(nc.Dependencies[0].Templates[0].Data); got != expect {
+		t.Errorf("Expected dependency to be stripped, got:\n%s", got)
+	}
+	if string(c.Templates[0].Data) != tpl {
+		t.Error("Expected original chart to be unchanged")
+	}
+
+	if _, err := StripDevAnnotations(c, []string{""}); err == nil {
+		t.Error("Expected an error for an empty prefix")
+	}
+}
@@ -0,0 +1,440 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode/utf8"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// CRDsDir is the directory of a chart holding CustomResourceDefinitions.
+const CRDsDir = "crds"
+
+var (
+	// docSeparator matches a YAML document separator on a line of its own.
+	docSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+	// crdAPIVersion and crdKind match the top-level fields of a CRD manifest.
+	crdAPIVersion = regexp.MustCompile(`(?m)^apiVersion:[ \t]*["']?apiextensions\.k8s\.io/`)
+	crdKind       = regexp.MustCompile(`(?m)^kind:[ \t]*["']?CustomResourceDefinition["']?[ \t]*$`)
+)
+
+// EstimateResourceCount estimates how many Kubernetes resources a chart and its
+// dependencies will produce, without rendering the templates.
+//
+// Each non-empty YAML document (separated by ---) in a template counts as one
+// resource. Partials (files beginning with _) and NOTES.txt are skipped.
+//
+// Because template logic is not evaluated, this is only an estimate. A document
+// wrapped in a conditional is counted even if the condition is false, and a
+// range that emits several resources from one document is counted once.
+func EstimateResourceCount(c *chart.Chart) (int, error) {
+	count := 0
+	for _, t := range c.Templates {
+		if isPartial(t) || path.Base(t.Name) == NotesName {
+			continue
+		}
+		for _, doc := range docSeparator.Split(string(t.Data), -1) {
+			if !isEmptyDocument(doc) {
+				count++
+			}
+		}
+	}
+	for _, dep := range c.Dependencies {
+		n, err := EstimateResourceCount(dep)
+		if err != nil {
+			return count, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// isEmptyDocument reports whether a YAML document has only whitespace and comments.
+func isEmptyDocument(doc string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitMultiDoc splits a stream of YAML documents, such as a rendered
+// template, on its --- separators.
+//
+// Each document is trimmed of surrounding whitespace, and documents that
+// contain only whitespace and comments are dropped, so a separator at the
+// start or end of the stream does not produce an empty document.
+func SplitMultiDoc(manifest string) []string {
+	docs := []string{}
+	for _, doc := range docSeparator.Split(manifest, -1) {
+		if !isEmptyDocument(doc) {
+			docs = append(docs, strings.TrimSpace(doc))
+		}
+	}
+	return docs
+}
+
+// ParseMultiDoc splits a stream of YAML documents like SplitMultiDoc, and
+// parses each of them. Every document must be a mapping.
+func ParseMultiDoc(manifest string) ([]map[string]interface{}, error) {
+	docs := SplitMultiDoc(manifest)
+	objs := make([]map[string]interface{}, 0, len(docs))
+	for i, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return objs, fmt.Errorf("cannot parse document %d: %s", i+1, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// ParseError is a syntax error in a template, found by TemplateAST.
+type ParseError struct {
+	// Template is the name of the template.
+	Template string
+	// Line is the 1-based line of the error.
+	Line int
+	// Column is the 1-based column of the first action on that line, which
+	// is where the error is unless the line has more than one action. It is
+	// zero if the line has no action.
+	Column int
+	// Message describes the error.
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Template, e.Line, e.Column, e.Message)
+}
+
+// TemplateAST parses a template without executing it, for static analysis.
+//
+// The template is parsed as Go text/template source with the default
+// delimiters. Functions are not checked, so a template may call functions
+// that only the rendering engine provides; before Go 1.17, they must be
+// builtins, sprig functions, or the engine's. The named templates it defines
+// with {{ define }} are parsed too, but as trees of their own: the returned
+// tree holds only the rest of the template. A syntax error is returned as a
+// *ParseError.
+func TemplateAST(t *chart.Template) (*parse.Tree, error) {
+	tree, err := parseTemplate(t, map[string]*parse.Tree{})
+	if err != nil {
+		return nil, newParseError(t, err)
+	}
+	return tree, nil
+}
+
+// ReferencedFunctions returns the names of the functions called by the
+// templates of c and its dependencies, sorted and without duplicates.
+//
+// The templates are parsed but not rendered, so every function that appears
+// is returned, even in a branch that would never run. This includes Go's
+// builtin template functions, such as "printf" and "eq", as well as those
+// from sprig and the rendering engine, such as "include" and "required". A
+// caller can compare the result with a function map to check that a chart
+// can be rendered with it. A syntax error is returned as a *ParseError.
+func ReferencedFunctions(c *chart.Chart) ([]string, error) {
+	funcs := map[string]bool{}
+	var err error
+	walkTemplates(c, "", func(_ string, t *chart.Template) bool {
+		// Parsing into a tree set keeps the bodies of {{ define }} blocks,
+		// which TemplateAST drops.
+		trees := map[string]*parse.Tree{}
+		if _, perr := parseTemplate(t, trees); perr != nil {
+			err = newParseError(t, perr)
+			return false
+		}
+		for _, tr := range trees {
+			collectFunctions(tr.Root, funcs)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(funcs))
+	for n := range funcs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// collectFunctions adds the names of the functions called under node to funcs.
+func collectFunctions(node parse.Node, funcs map[string]bool) {
+	walkTemplateNodes(node, func(n parse.Node) {
+		if id, ok := n.(*parse.IdentifierNode); ok {
+			funcs[id.Ident] = true
+		}
+	})
+}
+
+// walkTemplateNodes calls fn for node and each node below it.
+func walkTemplateNodes(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplateNodes(child, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplateNodes(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplateNodes(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplateNodes(arg, fn)
+		}
+	case *parse.ChainNode:
+		walkTemplateNodes(n.Node, fn)
+	case *parse.IfNode:
+		walkBranchNodes(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranchNodes(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranchNodes(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkTemplateNodes(n.Pipe, fn)
+	}
+}
+
+// walkBranchNodes calls fn for the nodes of an if, range, or with action.
+func walkBranchNodes(n *parse.BranchNode, fn func(parse.Node)) {
+	walkTemplateNodes(n.Pipe, fn)
+	walkTemplateNodes(n.List, fn)
+	walkTemplateNodes(n.ElseList, fn)
+}
+
+// newParseError converts an error from text/template/parse, which has the
+// form "template: NAME:LINE: MESSAGE", to a *ParseError.
+func newParseError(t *chart.Template, err error) error {
+	msg := strings.TrimPrefix(err.Error(), "template: "+t.Name+":")
+	i := strings.Index(msg, ": ")
+	if i < 0 {
+		return err
+	}
+	line, convErr := strconv.Atoi(msg[:i])
+	if convErr != nil {
+		return err
+	}
+	pe := &ParseError{Template: t.Name, Line: line, Message: msg[i+2:]}
+	lines := strings.Split(string(t.Data), "\n")
+	if line >= 1 && line <= len(lines) {
+		if col := strings.Index(lines[line-1], "{{"); col >= 0 {
+			pe.Column = utf8.RuneCountInString(lines[line-1][:col]) + 1
+		}
+	}
+	return pe
+}
+
+// CRDFiles returns the CustomResourceDefinitions in a chart.
+//
+// A template is returned if any of its YAML documents has an apiVersion in the
+// apiextensions.k8s.io group and a kind of CustomResourceDefinition. Files in
+// the chart's crds/ directory are always returned, as templates. CRDs must
+// be installed before the resources that use them, so installers can apply
+// these first.
+//
+// As with EstimateResourceCount, templates are not rendered, so only
+// fields written literally in a template are detected. Dependencies are
+// not searched.
+func CRDFiles(c *chart.Chart) []*chart.Template {
+	var crds []*chart.Template
+	for _, f := range c.Files {
+		if strings.HasPrefix(f.TypeUrl, CRDsDir+"/") {
+			crds = append(crds, &chart.Template{Name: f.TypeUrl, Data: f.Value})
+		}
+	}
+	for _, t := range c.Templates {
+		for _, doc := range docSeparator.Split(string(t.Data), -1) {
+			if crdAPIVersion.MatchString(doc) && crdKind.MatchString(doc) {
+				crds = append(crds, t)
+				break
+			}
+		}
+	}
+	return crds
+}
+
+// TemplatesMap returns the contents of a chart's templates, keyed by name.
+//
+// If two templates have the same name, the last one in c.Templates wins, as
+// it would when the chart is saved to disk. Dependencies are not included.
+func TemplatesMap(c *chart.Chart) map[string][]byte {
+	m := make(map[string][]byte, len(c.Templates))
+	for _, t := range c.Templates {
+		m[t.Name] = t.Data
+	}
+	return m
+}
+
+// Partials returns the templates of a chart that are partials: files, such as
+// templates/_helpers.tpl, whose names begin with an underscore.
+//
+// Partials hold definitions for other templates to include, and are not
+// rendered on their own. Dependencies are not included.
+func Partials(c *chart.Chart) []*chart.Template {
+	var partials []*chart.Template
+	for _, t := range c.Templates {
+		if isPartial(t) {
+			partials = append(partials, t)
+		}
+	}
+	return partials
+}
+
+// Renderables returns the templates of a chart that are not Partials.
+//
+// These are the templates whose output becomes part of a release, including
+// NOTES.txt. Dependencies are not included.
+func Renderables(c *chart.Chart) []*chart.Template {
+	var renderables []*chart.Template
+	for _, t := range c.Templates {
+		if !isPartial(t) {
+			renderables = append(renderables, t)
+		}
+	}
+	return renderables
+}
+
+// AllTemplates returns an iterator over the templates of c and all of its
+// dependencies, for use with range-over-func (Go 1.23 or later):
+//
+//	for chartPath, t := range chartutil.AllTemplates(c) {
+//		...
+//	}
+//
+// chartPath is the dot-separated path from c to the chart that owns the
+// template, such as "myapp" for c's own templates and "myapp.charts.redis"
+// for those of its redis dependency. A chart's templates are visited before
+// those of its dependencies.
+func AllTemplates(c *chart.Chart) func(yield func(chartPath string, t *chart.Template) bool) {
+	return func(yield func(string, *chart.Template) bool) {
+		walkTemplates(c, "", yield)
+	}
+}
+
+// walkTemplates calls yield for each template in c and its dependencies,
+// stopping when yield returns false. It reports whether the walk finished.
+func walkTemplates(c *chart.Chart, parent string, yield func(string, *chart.Template) bool) bool {
+	name := ""
+	if c.Metadata != nil {
+		name = c.Metadata.Name
+	}
+	if parent != "" {
+		name = parent + "." + ChartsDir + "." + name
+	}
+	for _, t := range c.Templates {
+		if !yield(name, t) {
+			return false
+		}
+	}
+	for _, dep := range c.Dependencies {
+		if !walkTemplates(dep, name, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPartial reports whether the base name of t begins with an underscore.
+func isPartial(t *chart.Template) bool {
+	return strings.HasPrefix(path.Base(t.Name), "_")
+}
+
+// TemplateLocation identifies a template within a chart and its dependencies.
+type TemplateLocation struct {
+	// ChartPath is the path to the chart that owns the template, in the form
+	// used by AllTemplates, such as "myapp.charts.redis".
+	ChartPath string
+	// Name is the name of the template, such as "templates/_helpers.tpl".
+	Name string
+}
+
+// DuplicateTemplate is a set of templates with identical content.
+type DuplicateTemplate struct {
+	// Digest is the SHA-256 digest, in hex, of the shared content.
+	Digest string
+	// Locations are the templates that share it, in the order that
+	// AllTemplates visits them.
+	Locations []TemplateLocation
+}
+
+// FindDuplicateTemplates returns the templates of c and its dependencies
+// whose content is identical to that of another template.
+//
+// This commonly happens when a helper such as _helpers.tpl is symlinked
+// between charts, since a packaged chart holds a separate copy of the file.
+// Duplicates are not an error, but they often define the same named
+// templates, and only one of those definitions wins at render time.
+//
+// Empty templates are ignored. The groups are ordered by their first
+// location.
+func FindDuplicateTemplates(c *chart.Chart) []DuplicateTemplate {
+	groups := map[string]int{}
+	dups := []DuplicateTemplate{}
+	walkTemplates(c, "", func(chartPath string, t *chart.Template) bool {
+		if len(bytes.TrimSpace(t.Data)) == 0 {
+			return true
+		}
+		sum := sha256.Sum256(t.Data)
+		digest := hex.EncodeToString(sum[:])
+		loc := TemplateLocation{ChartPath: chartPath, Name: t.Name}
+		if i, ok := groups[digest]; ok {
+			dups[i].Locations = append(dups[i].Locations, loc)
+		} else {
+			groups[digest] = len(dups)
+			dups = append(dups, DuplicateTemplate{Digest: digest, Locations: []TemplateLocation{loc}})
+		}
+		return true
+	})
+
+	out := []DuplicateTemplate{}
+	for _, d := range dups {
+		if len(d.Locations) > 1 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
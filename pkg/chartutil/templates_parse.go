@@ -0,0 +1,40 @@
+//go:build go1.17
+// +build go1.17
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"text/template/parse"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// parseTemplate parses t into trees, without checking the functions it
+// calls, and returns the tree of the template itself.
+//
+// parse.SkipFuncCheck requires Go 1.17; templates_parse_legacy.go checks
+// calls against known function names instead.
+func parseTemplate(t *chart.Template, trees map[string]*parse.Tree) (*parse.Tree, error) {
+	tree := parse.New(t.Name)
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(string(t.Data), "", "", trees); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
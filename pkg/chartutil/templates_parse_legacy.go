@@ -0,0 +1,64 @@
+//go:build !go1.17
+// +build !go1.17
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"text/template/parse"
+
+	"github.com/Masterminds/sprig"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// Before Go 1.17, the parser cannot skip checking the functions a template
+// calls, so templates are parsed with placeholders for every function they
+// may use: text/template's builtins, sprig's, and those of the rendering
+// engine.
+
+// templateFuncs holds a placeholder for each known template function. The
+// parser only checks that a name is present.
+var templateFuncs = func() map[string]interface{} {
+	f := map[string]interface{}{}
+	for name := range sprig.TxtFuncMap() {
+		f[name] = true
+	}
+	for _, name := range []string{
+		// text/template builtins.
+		"and", "call", "eq", "ge", "gt", "html", "index", "js", "le", "len",
+		"lt", "ne", "not", "or", "print", "printf", "println", "slice",
+		"urlquery",
+		// Rendering engine functions.
+		"fromJson", "fromYaml", "include", "lookup", "required", "toJson",
+		"toToml", "toYaml", "tpl",
+	} {
+		f[name] = true
+	}
+	return f
+}()
+
+// parseTemplate parses t into trees and returns the tree of the template
+// itself. Calls to functions that are not in templateFuncs are errors.
+func parseTemplate(t *chart.Template, trees map[string]*parse.Tree) (*parse.Tree, error) {
+	tree := parse.New(t.Name)
+	if _, err := tree.Parse(string(t.Data), "", "", trees, templateFuncs); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"text/template/parse"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const multiDocTemplate = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: one
+---
+# Just a comment
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: two
+---
+{{- if .Values.enabled }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: three
+{{- end }}
+`
+
+func TestEstimateResourceCount(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "multi"},
+		Templates: []*chart.Template{
+			{Name: "templates/multi.yaml", Data: []byte(multiDocTemplate)},
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "x" }}y{{ end }}`)},
+			{Name: "templates/NOTES.txt", Data: []byte("Thanks for installing.")},
+		},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata: &chart.Metadata{Name: "sub"},
+				Templates: []*chart.Template{
+					{Name: "templates/pod.yaml", Data: []byte("kind: Pod\n")},
+				},
+			},
+		},
+	}
+
+	n, err := EstimateResourceCount(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 resources, got %d", n)
+	}
+}
+
+func TestCRDFiles(t *testing.T) {
+	crd := `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: whales.example.com
+`
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "crds"},
+		Templates: []*chart.Template{
+			{Name: "templates/multi.yaml", Data: []byte(multiDocTemplate)},
+			{Name: "templates/whale-crd.yaml", Data: []byte(multiDocTemplate + "---\n" + crd)},
+			{Name: "templates/fake.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\ndata:\n  kind: CustomResourceDefinition\n")},
+		},
+		Files: []*any.Any{
+			{TypeUrl: "crds/ship.yaml", Value: []byte(crd)},
+			{TypeUrl: "README.md", Value: []byte(crd)},
+		},
+	}
+
+	crds := CRDFiles(c)
+	expect := []string{"crds/ship.yaml", "templates/whale-crd.yaml"}
+	if len(crds) != len(expect) {
+		t.Fatalf("Expected %d CRDs, got %d", len(expect), len(crds))
+	}
+	for i, name := range expect {
+		if crds[i].Name != name {
+			t.Errorf("Expected %s, got %s", name, crds[i].Name)
+		}
+	}
+}
+
+func TestTemplatesMap(t *testing.T) {
+	c := &chart.Chart{
+		Templates: []*chart.Template{
+			{Name: "templates/pod.yaml", Data: []byte("kind: Pod\n")},
+			{Name: "templates/svc.yaml", Data: []byte("kind: Service\n")},
+			{Name: "templates/pod.yaml", Data: []byte("kind: Pod\nmetadata: {}\n")},
+		},
+	}
+
+	m := TemplatesMap(c)
+	if len(m) != 2 {
+		t.Errorf("Expected 2 templates, got %d", len(m))
+	}
+	if got := string(m["templates/svc.yaml"]); got != "kind: Service\n" {
+		t.Errorf("Unexpected templates/svc.yaml: %q", got)
+	}
+	if got := string(m["templates/pod.yaml"]); got != "kind: Pod\nmetadata: {}\n" {
+		t.Errorf("Expected the last templates/pod.yaml to win, got %q", got)
+	}
+	if _, ok := m["templates/missing.yaml"]; ok {
+		t.Error("Expected no templates/missing.yaml")
+	}
+}
+
+func TestPartials(t *testing.T) {
+	c := &chart.Chart{
+		Templates: []*chart.Template{
+			{Name: "templates/_helpers.tpl"},
+			{Name: "templates/pod.yaml"},
+			{Name: "templates/NOTES.txt"},
+			{Name: "templates/sub/_labels.tpl"},
+			{Name: "templates/_sub/svc.yaml"},
+		},
+	}
+
+	partials := Partials(c)
+	if len(partials) != 2 || partials[0].Name != "templates/_helpers.tpl" || partials[1].Name != "templates/sub/_labels.tpl" {
+		t.Errorf("Unexpected partials: %v", partials)
+	}
+	renderables := Renderables(c)
+	if len(renderables) != 3 {
+		t.Fatalf("Expected 3 renderable templates, got %v", renderables)
+	}
+	for i, name := range []string{"templates/pod.yaml", "templates/NOTES.txt", "templates/_sub/svc.yaml"} {
+		if renderables[i].Name != name {
+			t.Errorf("Expected renderable %d to be %s, got %s", i, name, renderables[i].Name)
+		}
+	}
+}
+
+func TestAllTemplates(t *testing.T) {
+	c := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "myapp"},
+		Templates: []*chart.Template{{Name: "templates/deploy.yaml"}},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata:  &chart.Metadata{Name: "redis"},
+				Templates: []*chart.Template{{Name: "templates/master.yaml"}, {Name: "templates/slave.yaml"}},
+				Dependencies: []*chart.Chart{
+					{
+						Metadata:  &chart.Metadata{Name: "sentinel"},
+						Templates: []*chart.Template{{Name: "templates/sentinel.yaml"}},
+					},
+				},
+			},
+		},
+	}
+
+	var got []string
+	AllTemplates(c)(func(chartPath string, tpl *chart.Template) bool {
+		got = append(got, chartPath+":"+tpl.Name)
+		return true
+	})
+	expect := []string{
+		"myapp:templates/deploy.yaml",
+		"myapp.charts.redis:templates/master.yaml",
+		"myapp.charts.redis:templates/slave.yaml",
+		"myapp.charts.redis.charts.sentinel:templates/sentinel.yaml",
+	}
+	if len(got) != len(expect) {
+		t.Fatalf("Expected %v, got %v", expect, got)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("Expected %s, got %s", expect[i], got[i])
+		}
+	}
+
+	// Stopping early, as a break in a range loop does, ends the walk.
+	n := 0
+	AllTemplates(c)(func(string, *chart.Template) bool {
+		n++
+		return n < 2
+	})
+	if n != 2 {
+		t.Errorf("Expected iteration to stop after 2 templates, got %d", n)
+	}
+}
+
+func TestSplitMultiDoc(t *testing.T) {
+	manifest := `---
+kind: Service
+metadata:
+  name: web
+---
+# Source: ahab/templates/empty.yaml
+
+---
+kind: Deployment
+---
+`
+	expect := []string{"kind: Service\nmetadata:\n  name: web", "kind: Deployment"}
+	if got := SplitMultiDoc(manifest); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Expected %q, got %q", expect, got)
+	}
+	if got := SplitMultiDoc("\n---\n"); len(got) != 0 {
+		t.Errorf("Expected no documents, got %q", got)
+	}
+
+	objs, err := ParseMultiDoc(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 || objs[0]["kind"] != "Service" || objs[1]["kind"] != "Deployment" {
+		t.Errorf("Expected a Service and a Deployment, got %v", objs)
+	}
+	if _, err := ParseMultiDoc("kind: Service\n---\n- not a map\n"); err == nil || !strings.Contains(err.Error(), "document 2") {
+		t.Errorf("Expected an error for document 2, got %v", err)
+	}
+}
+
+func TestTemplateAST(t *testing.T) {
+	tpl := &chart.Template{
+		Name: "templates/pod.yaml",
+		Data: []byte(`{{ define "ahab.name" }}ahab{{ end }}
+name: {{ template "ahab.name" . }}
+password: {{ .Values.password | b64enc | quote }}
+`),
+	}
+	tree, err := TemplateAST(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, n := range tree.Root.Nodes {
+		if a, ok := n.(*parse.ActionNode); ok {
+			for _, cmd := range a.Pipe.Cmds {
+				if f, ok := cmd.Args[0].(*parse.FieldNode); ok {
+					fields = append(fields, f.String())
+				}
+			}
+		}
+	}
+	if !reflect.DeepEqual(fields, []string{".Values.password"}) {
+		t.Errorf("Expected a reference to .Values.password, got %v", fields)
+	}
+
+	tpl.Data = []byte("kind: Pod\nspec:\n  x: {{ if .Values.x }}y\n")
+	_, err = TemplateAST(tpl)
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Expected a *ParseError, got %v", err)
+	}
+	if pe.Template != "templates/pod.yaml" || pe.Line != 4 || pe.Column != 0 {
+		t.Errorf("Expected an error at the end of templates/pod.yaml, got %s", pe)
+	}
+
+	tpl.Data = []byte("kind: Pod\n  x: {{ end }}\n")
+	_, err = TemplateAST(tpl)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 2 || pe.Column != 6 || !strings.Contains(pe.Message, "unexpected {{end}}") {
+		t.Errorf("Expected an unexpected end at 2:6, got %v", err)
+	}
+}
+
+func TestFindDuplicateTemplates(t *testing.T) {
+	helpers := []byte(`{{ define "crew.name" }}{{ .Chart.Name }}{{ end }}`)
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab"},
+		Templates: []*chart.Template{
+			{Name: "templates/_helpers.tpl", Data: helpers},
+			{Name: "templates/deployment.yaml", Data: []byte("kind: Deployment")},
+			{Name: "templates/empty.yaml", Data: []byte("\n")},
+		},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata: &chart.Metadata{Name: "starbuck"},
+				Templates: []*chart.Template{
+					{Name: "templates/_helpers.tpl", Data: helpers},
+					{Name: "templates/empty.yaml", Data: []byte("\n")},
+				},
+			},
+			{
+				Metadata: &chart.Metadata{Name: "stubb"},
+				Templates: []*chart.Template{
+					{Name: "templates/deployment.yaml", Data: []byte("kind: StatefulSet")},
+				},
+			},
+		},
+	}
+
+	dups := FindDuplicateTemplates(c)
+	if len(dups) != 1 {
+		t.Fatalf("Expected 1 duplicate, got %v", dups)
+	}
+	expect := []TemplateLocation{
+		{ChartPath: "ahab", Name: "templates/_helpers.tpl"},
+		{ChartPath: "ahab.charts.starbuck", Name: "templates/_helpers.tpl"},
+	}
+	if !reflect.DeepEqual(dups[0].Locations, expect) {
+		t.Errorf("Expected %v, got %v", expect, dups[0].Locations)
+	}
+	if len(dups[0].Digest) != 64 {
+		t.Errorf("Expected a SHA-256 digest, got %q", dups[0].Digest)
+	}
+
+	c.Dependencies[0].Templates[0].Data = []byte(`{{ define "crew.name" }}starbuck{{ end }}`)
+	if dups := FindDuplicateTemplates(c); len(dups) != 0 {
+		t.Errorf("Expected no duplicates, got %v", dups)
+	}
+}
+
+func TestReferencedFunctions(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab"},
+		Templates: []*chart.Template{
+			{Name: "templates/_helpers.tpl", Data: []byte(`{{ define "ahab.name" }}{{ .Chart.Name | trunc 63 | trimSuffix "-" }}{{ end }}`)},
+			{Name: "templates/deployment.yaml", Data: []byte(`name: {{ include "ahab.name" . | quote }}
+{{- if and .Values.crew (eq .Values.ship "Pequod") }}
+{{- range $k, $v := .Values.crew }}
+{{ printf "%s" (upper $k) }}: {{ $v }}
+{{- else }}
+{{ required "crew is required" .Values.crew }}
+{{- end }}
+{{- end }}`)},
+		},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata: &chart.Metadata{Name: "starbuck"},
+				Templates: []*chart.Template{
+					{Name: "templates/configmap.yaml", Data: []byte(`{{ with .Values.data }}{{ toYaml . | indent 2 }}{{ end }}`)},
+				},
+			},
+		},
+	}
+
+	funcs, err := ReferencedFunctions(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"and", "eq", "include", "indent", "printf", "quote", "required", "toYaml", "trimSuffix", "trunc", "upper"}
+	if !reflect.DeepEqual(funcs, expect) {
+		t.Errorf("Expected %v, got %v", expect, funcs)
+	}
+
+	c.Dependencies[0].Templates[0].Data = []byte(`{{ with .Values.data }}`)
+	if _, err := ReferencedFunctions(c); err == nil {
+		t.Error("Expected a parse error")
+	} else if pe, ok := err.(*ParseError); !ok || pe.Template != "templates/configmap.yaml" {
+		t.Errorf("Expected a *ParseError for templates/configmap.yaml, got %v", err)
+	}
+}
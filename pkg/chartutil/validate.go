@@ -0,0 +1,313 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver"
+	"github.com/Masterminds/sprig"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+const (
+	// ReadmeName is the name of the chart's README file.
+	ReadmeName = "README.md"
+	// NotesPath is the path of the chart's usage notes template.
+	NotesPath = TemplatesDir + "/" + NotesName
+)
+
+// RequiredFilesPresent checks a loaded chart for files every chart should have.
+//
+// It returns the names of any missing required files (Chart.yaml and
+// values.yaml). The absence of optional files (templates/NOTES.txt and
+// README.md) is logged as a warning, but not returned.
+func RequiredFilesPresent(c *chart.Chart) []string {
+	missing := []string{}
+	if c.Metadata == nil {
+		missing = append(missing, ChartfileName)
+	}
+	if c.Values == nil {
+		missing = append(missing, ValuesfileName)
+	}
+
+	hasNotes := false
+	for _, t := range c.Templates {
+		if t.Name == NotesPath {
+			hasNotes = true
+			break
+		}
+	}
+	if !hasNotes {
+		log.Printf("warning: chart has no %s", NotesPath)
+	}
+
+	hasReadme := false
+	for _, f := range c.Files {
+		if f.TypeUrl == ReadmeName {
+			hasReadme = true
+			break
+		}
+	}
+	if !hasReadme {
+		log.Printf("warning: chart has no %s", ReadmeName)
+	}
+
+	return missing
+}
+
+// chartNameRegexp is the grammar chart names must follow: lowercase letters and
+// numbers, optionally separated by dashes.
+var chartNameRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidateOption allows specifying various settings configurable by the caller
+// for overriding the defaults used when validating a chart.
+type ValidateOption func(*ValidateOptions)
+
+// ValidateOptions specify optional settings used when validating a chart.
+type ValidateOptions struct {
+	// FuncMap is the set of functions templates are parsed with.
+	FuncMap template.FuncMap
+}
+
+// WithFuncMap sets the functions that templates may refer to.
+//
+// By default, templates are parsed with the Sprig functions, plus placeholders
+// for "toYaml" and "include". Callers that render with a different engine
+// should pass its functions here.
+func WithFuncMap(f template.FuncMap) ValidateOption {
+	return func(opts *ValidateOptions) {
+		opts.FuncMap = f
+	}
+}
+
+// defaultFuncMap returns the functions templates are parsed with by default.
+func defaultFuncMap() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	f["toYaml"] = func(interface{}) string { return "" }
+	f["include"] = func(string, interface{}) string { return "" }
+	return f
+}
+
+// Validate runs all of the structural checks on a chart and its dependencies.
+//
+// This checks that the metadata is complete, that the version is SemVer, that
+// the name follows the chart naming rules, that every requirement is satisfied
+// by a dependency, and that each template parses. Rather than stopping at the
+// first problem, all findings are returned. Findings for dependencies are
+// prefixed with the dependency's path.
+func Validate(c *chart.Chart, opts ...ValidateOption) []error {
+	o := &ValidateOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.FuncMap == nil {
+		o.FuncMap = defaultFuncMap()
+	}
+	return validate(c, "", o)
+}
+
+func validate(c *chart.Chart, prefix string, o *ValidateOptions) []error {
+	errs := []error{}
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s%s", prefix, err))
+		}
+	}
+
+	if c.Metadata == nil {
+		add(errors.New("chart metadata (Chart.yaml) missing"))
+		return errs
+	}
+	for _, err := range validateMetadata(c.Metadata) {
+		add(err)
+	}
+	for _, err := range validateDependencies(c) {
+		add(err)
+	}
+	for _, t := range c.Templates {
+		add(validateTemplate(t, o.FuncMap))
+	}
+
+	for _, dep := range c.Dependencies {
+		name := "<unknown>"
+		if dep.Metadata != nil {
+			name = dep.Metadata.Name
+		}
+		errs = append(errs, validate(dep, prefix+ChartsDir+"/"+name+": ", o)...)
+	}
+	return errs
+}
+
+// validateMetadata checks the fields of Chart.yaml.
+func validateMetadata(m *chart.Metadata) []error {
+	errs := []error{}
+	if m.Name == "" {
+		errs = append(errs, errors.New("Chart.yaml: name is required"))
+	} else if !chartNameRegexp.MatchString(m.Name) {
+		errs = append(errs, fmt.Errorf("Chart.yaml: name %q must be lowercase letters and numbers separated by dashes", m.Name))
+	}
+
+	if m.Version == "" {
+		errs = append(errs, errors.New("Chart.yaml: version is required"))
+	} else if _, err := semver.NewVersion(m.Version); err != nil {
+		errs = append(errs, fmt.Errorf("Chart.yaml: version %q is not a valid SemVer", m.Version))
+	}
+
+	for i, mt := range m.Maintainers {
+		if mt.Name == "" {
+			errs = append(errs, fmt.Errorf("Chart.yaml: maintainer %d has no name", i+1))
+		}
+		if mt.Email == "" {
+			continue
+		}
+		if addr, err := mail.ParseAddress(mt.Email); err != nil || addr.Address != mt.Email {
+			errs = append(errs, fmt.Errorf("Chart.yaml: maintainer %q has invalid email %q", mt.Name, mt.Email))
+		}
+	}
+
+	if m.Icon != "" && !validIcon(m.Icon) {
+		errs = append(errs, fmt.Errorf("Chart.yaml: icon %q must be an http(s) URL or a data URI", m.Icon))
+	}
+	return errs
+}
+
+// validIcon reports whether icon is an absolute http(s) URL or an image data URI.
+func validIcon(icon string) bool {
+	if strings.HasPrefix(icon, "data:") {
+		return strings.HasPrefix(icon, "data:image/") && strings.Contains(icon, ",")
+	}
+	u, err := url.Parse(icon)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validateDependencies checks that each dependency the chart declares, in
+// Chart.yaml or requirements.yaml according to its apiVersion, is satisfied
+// by a loaded dependency.
+func validateDependencies(c *chart.Chart) []error {
+	errs := []error{}
+	source := requirementsName
+	if APIVersion(c) == ApiVersionV2 {
+		source = ChartfileName
+	}
+	reqs, err := LoadDependencies(c)
+	if err != nil {
+		return append(errs, fmt.Errorf("%s: %s", source, err))
+	}
+
+	deps := map[string]*chart.Metadata{}
+	for _, dep := range c.Dependencies {
+		if dep.Metadata != nil {
+			deps[dep.Metadata.Name] = dep.Metadata
+		}
+	}
+
+	for _, r := range reqs {
+		m, ok := deps[r.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: dependency %q is not present in %s/", source, r.Name, ChartsDir))
+			continue
+		}
+		if r.Version == "" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(r.Version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: dependency %q has invalid version %q", source, r.Name, r.Version))
+			continue
+		}
+		v, err := semver.NewVersion(m.Version)
+		if err != nil || !constraint.Check(v) {
+			errs = append(errs, fmt.Errorf("%s: dependency %q version %q does not satisfy %q", source, r.Name, m.Version, r.Version))
+		}
+	}
+	return errs
+}
+
+// validateTemplate checks that a template parses.
+func validateTemplate(t *chart.Template, funcs template.FuncMap) error {
+	if !strings.HasPrefix(t.Name, TemplatesDir+"/") {
+		return fmt.Errorf("%s: template is outside of %s/", t.Name, TemplatesDir)
+	}
+	if _, err := template.New(path.Base(t.Name)).Funcs(funcs).Parse(string(t.Data)); err != nil {
+		return fmt.Errorf("%s: %s", t.Name, err)
+	}
+	return nil
+}
+
+// DefaultHygienePatterns are the file name patterns HygieneCheck looks for by default.
+//
+// These match OS metadata files and editor backup and swap files.
+var DefaultHygienePatterns = []string{
+	".DS_Store",
+	"Thumbs.db",
+	"*.swp",
+	"*.swo",
+	"*~",
+	"#*#",
+	".#*",
+	"*.bak",
+	"*.orig",
+}
+
+// HygieneCheck looks for commonly-unwanted files that were packaged in a chart.
+//
+// Each pattern is matched against the base name of every template and file,
+// using path.Match syntax. If patterns is empty, DefaultHygienePatterns is
+// used. The returned names are relative to the top of the chart, and include
+// files in dependencies.
+func HygieneCheck(c *chart.Chart, patterns []string) []string {
+	if len(patterns) == 0 {
+		patterns = DefaultHygienePatterns
+	}
+	return hygieneCheck(c, patterns, "")
+}
+
+func hygieneCheck(c *chart.Chart, patterns []string, prefix string) []string {
+	found := []string{}
+	check := func(name string) {
+		base := path.Base(name)
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, base); ok {
+				found = append(found, prefix+name)
+				return
+			}
+		}
+	}
+
+	for _, t := range c.Templates {
+		check(t.Name)
+	}
+	for _, f := range c.Files {
+		check(f.TypeUrl)
+	}
+	for _, dep := range c.Dependencies {
+		if dep.Metadata != nil {
+			found = append(found, hygieneCheck(dep, patterns, prefix+ChartsDir+"/"+dep.Metadata.Name+"/")...)
+		}
+	}
+	return found
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestRequiredFilesPresent(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	if missing := RequiredFilesPresent(c); len(missing) != 0 {
+		t.Errorf("Expected no missing files, got %v", missing)
+	}
+
+	missing := RequiredFilesPresent(&chart.Chart{})
+	if len(missing) != 2 || missing[0] != ChartfileName || missing[1] != ValuesfileName {
+		t.Errorf("Expected Chart.yaml and values.yaml to be missing, got %v", missing)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c, err := Load("testdata/frobnitz")
+	if err != nil {
+		t.Fatalf("Failed to load testdata: %s", err)
+	}
+	if errs := Validate(c); len(errs) != 0 {
+		t.Errorf("Expected frobnitz to be valid, got %v", errs)
+	}
+
+	c = &chart.Chart{
+		Metadata: &chart.Metadata{Name: "Bad_Name", Version: "one"},
+		Templates: []*chart.Template{
+			{Name: "templates/good.yaml", Data: []byte(`name: {{ .Values.name | quote }}`)},
+			{Name: "templates/bad.yaml", Data: []byte(`name: {{ .Values.name `)},
+		},
+		Files: []*any.Any{
+			{TypeUrl: "requirements.yaml", Value: []byte("dependencies:\n- name: missing\n  version: 1.0.0\n- name: sub\n  version: ^2.0.0\n")},
+		},
+		Dependencies: []*chart.Chart{
+			{Metadata: &chart.Metadata{Name: "sub", Version: "1.0.0"}},
+		},
+	}
+
+	expect := []string{
+		`Chart.yaml: name "Bad_Name"`,
+		`Chart.yaml: version "one" is not a valid SemVer`,
+		`dependency "missing" is not present`,
+		`dependency "sub" version "1.0.0" does not satisfy "^2.0.0"`,
+		`templates/bad.yaml:`,
+	}
+	errs := Validate(c)
+	if len(errs) != len(expect) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for i, e := range expect {
+		if !strings.Contains(errs[i].Error(), e) {
+			t.Errorf("Expected error %d to contain %q, got %q", i, e, errs[i])
+		}
+	}
+}
+
+func TestValidateDependenciesV2(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:       "ahab",
+			Version:    "0.1.0",
+			ApiVersion: ApiVersionV2,
+			Dependencies: []*chart.Dependency{
+				{Name: "missing", Version: "1.0.0"},
+				{Name: "sub", Version: "^2.0.0"},
+			},
+		},
+		Files: []*any.Any{
+			// Ignored, since the chart is apiVersion v2.
+			{TypeUrl: "requirements.yaml", Value: []byte("dependencies:\n- name: ignored\n  version: 1.0.0\n")},
+		},
+		Dependencies: []*chart.Chart{
+			{Metadata: &chart.Metadata{Name: "sub", Version: "1.0.0"}},
+		},
+	}
+
+	expect := []string{
+		`Chart.yaml: dependency "missing" is not present`,
+		`Chart.yaml: dependency "sub" version "1.0.0" does not satisfy "^2.0.0"`,
+	}
+	errs := validateDependencies(c)
+	if len(errs) != len(expect) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for i, e := range expect {
+		if !strings.Contains(errs[i].Error(), e) {
+			t.Errorf("Expected error %d to contain %q, got %q", i, e, errs[i])
+		}
+	}
+
+	c.Metadata.Dependencies = c.Metadata.Dependencies[1:]
+	c.Metadata.Dependencies[0].Version = "^1.0.0"
+	if errs := validateDependencies(c); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateMaintainersAndIcon(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:    "ahab",
+			Version: "0.1.0",
+			Icon:    "data:image/png;base64,iVBORw0KGgo=",
+			Maintainers: []*chart.Maintainer{
+				{Name: "Ahab", Email: "ahab@example.com"},
+				{Name: "Starbuck"},
+			},
+		},
+	}
+	if errs := Validate(c); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+
+	c.Metadata.Icon = "ftp://example.com/icon.png"
+	c.Metadata.Maintainers = []*chart.Maintainer{
+		{Name: "Ahab", Email: "ahab-at-example.com"},
+		{Name: "Stubb", Email: "Stubb <stubb@example.com>"},
+		{Email: "flask@example.com"},
+	}
+	expect := []string{
+		`Chart.yaml: maintainer "Ahab" has invalid email "ahab-at-example.com"`,
+		`Chart.yaml: maintainer "Stubb" has invalid email "Stubb <stubb@example.com>"`,
+		`Chart.yaml: maintainer 3 has no name`,
+		`Chart.yaml: icon "ftp://example.com/icon.png" must be an http(s) URL or a data URI`,
+	}
+	errs := Validate(c)
+	if len(errs) != len(expect) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for i, e := range expect {
+		if errs[i].Error() != e {
+			t.Errorf("Expected %q, got %q", e, errs[i])
+		}
+	}
+
+	for _, icon := range []string{"not a url", "/icon.png", "data:text/html,<script>"} {
+		c.Metadata.Icon = icon
+		c.Metadata.Maintainers = nil
+		if errs := Validate(c); len(errs) != 1 {
+			t.Errorf("Expected icon %q to be invalid, got %v", icon, errs)
+		}
+	}
+}
+
+func TestValidateDependencyPrefix(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "top", Version: "0.1.0"},
+		Dependencies: []*chart.Chart{
+			{Metadata: &chart.Metadata{Name: "sub"}},
+		},
+	}
+	errs := Validate(c)
+	if len(errs) != 1 || errs[0].Error() != "charts/sub: Chart.yaml: version is required" {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
+
+func TestHygieneCheck(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "messy"},
+		Templates: []*chart.Template{
+			{Name: "templates/pod.yaml"},
+			{Name: "templates/.pod.yaml.swp"},
+		},
+		Files: []*any.Any{
+			{TypeUrl: ".DS_Store"},
+			{TypeUrl: "README.md"},
+			{TypeUrl: "docs/notes.txt~"},
+		},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata: &chart.Metadata{Name: "sub"},
+				Files:    []*any.Any{{TypeUrl: "files/.DS_Store"}},
+			},
+		},
+	}
+
+	found := HygieneCheck(c, nil)
+	expect := []string{"templates/.pod.yaml.swp", ".DS_Store", "docs/notes.txt~", "charts/sub/files/.DS_Store"}
+	if strings.Join(found, ",") != strings.Join(expect, ",") {
+		t.Errorf("Expected %v, got %v", expect, found)
+	}
+
+	found = HygieneCheck(c, []string{"*.md"})
+	if len(found) != 1 || found[0] != "README.md" {
+		t.Errorf("Expected only README.md with a custom pattern, got %v", found)
+	}
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package chartutil
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"os"
+	"regexp"
 	"strings"
 
 	"github.com/ghodss/yaml"
@@ -79,6 +83,33 @@ func (v Values) AsMap() map[string]interface{} {
 	return v
 }
 
+// ContextKey is the name of the top-level variable that holds the template
+// context, as in {{ .Context.featureFlag }}.
+const ContextKey = "Context"
+
+// GetContext returns the template context from a set of render values, such as
+// those returned by ToRenderValues.
+//
+// An empty map is returned if no context is set.
+func (v Values) GetContext() map[string]interface{} {
+	if ctx, ok := v[ContextKey].(map[string]interface{}); ok {
+		return ctx
+	}
+	return map[string]interface{}{}
+}
+
+// SetContext sets the template context in a set of render values.
+//
+// Unlike .Values, the context is not scoped by chart: subcharts see the same
+// .Context as the chart being installed. A nil ctx is stored as an empty map,
+// so that templates can always index into .Context.
+func (v Values) SetContext(ctx map[string]interface{}) {
+	if ctx == nil {
+		ctx = map[string]interface{}{}
+	}
+	v[ContextKey] = ctx
+}
+
 // Encode writes serialized Values information to the given io.Writer.
 func (v Values) Encode(w io.Writer) error {
 	//return yaml.NewEncoder(w).Encode(v)
@@ -128,6 +159,112 @@ func ReadValuesFile(filename string) (Values, error) {
 	return ReadValues(data)
 }
 
+// envRefPattern matches a ${VAR} reference to an environment variable.
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces each ${VAR} in data with the value of VAR in env, or in
+// the process environment if env is nil.
+//
+// Unset variables are replaced with the empty string, unless failUnset is
+// true, in which case an error naming them is returned.
+func expandEnv(data []byte, env map[string]string, failUnset bool) ([]byte, error) {
+	lookup := os.LookupEnv
+	if env != nil {
+		lookup = func(k string) (string, bool) {
+			v, ok := env[k]
+			return v, ok
+		}
+	}
+
+	var unset []string
+	out := envRefPattern.ReplaceAllFunc(data, func(ref []byte) []byte {
+		name := string(ref[2 : len(ref)-1])
+		v, ok := lookup(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return []byte(v)
+	})
+	if failUnset && len(unset) > 0 {
+		return nil, fmt.Errorf("environment variables not set: %s", strings.Join(unset, ", "))
+	}
+	return out, nil
+}
+
+// EncodeValues serializes values as base64-encoded JSON.
+//
+// This is the format some secret stores use to hold values as a single
+// string. DecodeValues reverses it.
+func EncodeValues(vals map[string]interface{}) (string, error) {
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecodeValues parses values that were encoded by EncodeValues.
+//
+// As with ReadValues, numbers are decoded as float64.
+func DecodeValues(encoded string) (map[string]interface{}, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("values are not valid base64: %s", err)
+	}
+	vals := map[string]interface{}{}
+	if err := json.Unmarshal(data, &vals); err != nil {
+		return nil, fmt.Errorf("values are not valid JSON: %s", err)
+	}
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+	return vals, nil
+}
+
+// ValuesFiles returns the alternate values files in the top level of a chart.
+//
+// Files such as values-prod.yaml or values-dev.yaml are loaded into c.Files,
+// and are returned here keyed by file name. The canonical values.yaml is stored
+// in c.Values, and is not included.
+func ValuesFiles(c *chart.Chart) map[string][]byte {
+	files := map[string][]byte{}
+	for _, f := range c.Files {
+		n := f.TypeUrl
+		if strings.Contains(n, "/") || n == ValuesfileName {
+			continue
+		}
+		if strings.HasPrefix(n, "values") && strings.HasSuffix(n, ".yaml") {
+			files[n] = f.Value
+		}
+	}
+	return files
+}
+
+// LoadAndMergeValues loads the chart at name, and merges overrides onto the
+// chart's values.yaml.
+//
+// The merge is deep: when both sides have a map for the same key, the maps are
+// merged key by key, with overrides winning. Any other value in overrides,
+// including a list, replaces the chart's value outright; lists are never
+// merged element by element. Only the top-level chart's values are merged;
+// use CoalesceValues to also bring in the values of dependencies.
+//
+// overrides is not modified.
+func LoadAndMergeValues(name string, overrides map[string]interface{}) (*chart.Chart, map[string]interface{}, error) {
+	c, err := Load(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals := Values{}
+	if c.Values != nil {
+		if vals, err = ReadValues([]byte(c.Values.Raw)); err != nil {
+			return c, nil, fmt.Errorf("cannot parse values.yaml: %s", err)
+		}
+	}
+	merged := copyValue(overrides).(map[string]interface{})
+	return c, coalesceTables(merged, vals), nil
+}
+
 // CoalesceValues coalesces all of the values in a chart (and its subcharts).
 //
 // Values are coalesced together using the following rules:
@@ -304,6 +441,9 @@ type ReleaseOptions struct {
 	Name      string
 	Time      *timestamp.Timestamp
 	Namespace string
+	// TemplateContext is extra data, such as cluster-specific feature flags,
+	// made available to templates as .Context.
+	TemplateContext map[string]interface{}
 }
 
 // ToRenderValues composes the struct from the data coming from the Releases, Charts and Values files
@@ -319,6 +459,7 @@ func ToRenderValues(chrt *chart.Chart, chrtVals *chart.Config, options ReleaseOp
 		"Chart": chrt.Metadata,
 		"Files": NewFiles(chrt.Files),
 	}
+	Values(top).SetContext(options.TemplateContext)
 
 	vals, err := CoalesceValues(chrt, chrtVals)
 	if err != nil {
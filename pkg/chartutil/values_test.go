@@ -20,6 +20,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"text/template"
 
@@ -121,6 +125,9 @@ where:
 	if data := res["Files"].(Files)["scheherazade/shahryar.txt"]; string(data) != "1,001 Nights" {
 		t.Errorf("Expected file '1,001 Nights', got %q", string(data))
 	}
+	if ctx := res.GetContext(); len(ctx) != 0 {
+		t.Errorf("Expected an empty context, got %v", ctx)
+	}
 
 	var vals Values
 	vals = res["Values"].(Values)
@@ -141,6 +148,117 @@ where:
 	}
 }
 
+func TestValuesFiles(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "envs"},
+		Values:   &chart.Config{Raw: "env: default"},
+		Files: []*any.Any{
+			{TypeUrl: "values-prod.yaml", Value: []byte("env: prod")},
+			{TypeUrl: "values-dev.yaml", Value: []byte("env: dev")},
+			{TypeUrl: "docs/values-docs.yaml", Value: []byte("env: docs")},
+			{TypeUrl: "README.md", Value: []byte("# envs")},
+		},
+	}
+
+	files := ValuesFiles(c)
+	if len(files) != 2 {
+		t.Errorf("Expected 2 values files, got %d", len(files))
+	}
+	for name, expect := range map[string]string{"values-prod.yaml": "env: prod", "values-dev.yaml": "env: dev"} {
+		if got := string(files[name]); got != expect {
+			t.Errorf("Expected %s to contain %q, got %q", name, expect, got)
+		}
+	}
+}
+
+func TestEncodeValues(t *testing.T) {
+	vals := map[string]interface{}{
+		"name":    "Ishmael",
+		"ship":    "Pequod ⚓ 白鯨",
+		"crew":    float64(30),
+		"captain": nil,
+		"sailing": true,
+		"ports":   []interface{}{"Nantucket", float64(2), nil, map[string]interface{}{"name": "Cape Horn"}},
+		"hold": map[string]interface{}{
+			"barrels": map[string]interface{}{"oil": float64(1.5), "empty": []interface{}{}},
+		},
+	}
+
+	encoded, err := EncodeValues(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeValues(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vals, decoded) {
+		t.Errorf("Expected %v, got %v", vals, decoded)
+	}
+
+	if _, err := DecodeValues("not base64!"); err == nil {
+		t.Error("Expected an error for invalid base64")
+	}
+	if _, err := DecodeValues("WzEsMl0="); err == nil {
+		t.Error("Expected an error for a JSON array")
+	}
+
+	empty, err := DecodeValues("bnVsbA==")
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("Expected null to decode to an empty map, got %v (%v)", empty, err)
+	}
+}
+
+func TestLoadAndMergeValues(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := ioutil.WriteFile(filepath.Join(tmp, ChartfileName), []byte("name: pequod\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	values := `captain: Ahab
+crew:
+  mates:
+    first: Starbuck
+    second: Stubb
+  harpooners: [Queequeg, Tashtego]
+`
+	if err := ioutil.WriteFile(filepath.Join(tmp, ValuesfileName), []byte(values), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := map[string]interface{}{
+		"crew": map[string]interface{}{
+			"mates":      map[string]interface{}{"second": "Flask"},
+			"harpooners": []interface{}{"Daggoo"},
+		},
+	}
+	c, vals, err := LoadAndMergeValues(tmp, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Metadata.Name != "pequod" {
+		t.Errorf("Expected chart pequod, got %q", c.Metadata.Name)
+	}
+
+	expect := map[string]interface{}{
+		"captain": "Ahab",
+		"crew": map[string]interface{}{
+			"mates":      map[string]interface{}{"first": "Starbuck", "second": "Flask"},
+			"harpooners": []interface{}{"Daggoo"},
+		},
+	}
+	if !reflect.DeepEqual(vals, expect) {
+		t.Errorf("Expected %v, got %v", expect, vals)
+	}
+	if _, ok := overrides["captain"]; ok {
+		t.Error("Expected overrides to be unchanged")
+	}
+}
+
 func TestReadValuesFile(t *testing.T) {
 	data, err := ReadValuesFile("./testdata/coleridge.yaml")
 	if err != nil {
@@ -366,3 +484,27 @@ func TestCoalesceTables(t *testing.T) {
 		t.Errorf("Expected boat string, got %v", dst["boat"])
 	}
 }
+
+func TestTemplateContext(t *testing.T) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "test"}}
+	o := ReleaseOptions{
+		Name:            "Seven Voyages",
+		TemplateContext: map[string]interface{}{"ingress": true},
+	}
+	res, err := ToRenderValues(c, &chart.Config{}, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx := res.GetContext(); ctx["ingress"] != true {
+		t.Errorf("Expected ingress in context, got %v", ctx)
+	}
+
+	res.SetContext(map[string]interface{}{"ingress": false})
+	if ctx := res.GetContext(); ctx["ingress"] != false {
+		t.Errorf("Expected ingress to be replaced, got %v", ctx)
+	}
+	res.SetContext(nil)
+	if ctx, ok := res[ContextKey].(map[string]interface{}); !ok || len(ctx) != 0 {
+		t.Errorf("Expected a nil context to be stored as an empty map, got %v", res[ContextKey])
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+	"github.com/golang/protobuf/proto"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// IncrementVersion returns a copy of c with its version bumped.
+//
+// component is one of "major", "minor", or "patch". The lower components are
+// reset to zero, so bumping the minor version of 1.2.3 gives 1.3.0. Any
+// pre-release or build metadata is dropped. An error is returned if the
+// chart's version is not a valid SemVer 2 version.
+func IncrementVersion(c *chart.Chart, component string) (*chart.Chart, error) {
+	if c.Metadata == nil {
+		return nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+	v, err := semver.NewVersion(c.Metadata.Version)
+	if err != nil {
+		return nil, fmt.Errorf("version %q is not SemVerV2 compliant: %s", c.Metadata.Version, err)
+	}
+
+	major, minor, patch := v.Major(), v.Minor(), v.Patch()
+	switch component {
+	case "major":
+		major, minor, patch = major+1, 0, 0
+	case "minor":
+		minor, patch = minor+1, 0
+	case "patch":
+		patch++
+	default:
+		return nil, fmt.Errorf("unknown version component %q: must be major, minor, or patch", component)
+	}
+
+	nc := proto.Clone(c).(*chart.Chart)
+	nc.Metadata.Version = fmt.Sprintf("%d.%d.%d", major, minor, patch)
+	return nc, nil
+}
+
+// GetKubeVersion returns the range of Kubernetes versions a chart supports,
+// from the kubeVersion field of its Chart.yaml, such as ">=1.19".
+//
+// If the chart does not declare a kubeVersion, GetKubeVersion returns nil and
+// no error.
+func GetKubeVersion(c *chart.Chart) (*semver.Constraints, error) {
+	if c.Metadata == nil {
+		return nil, errors.New("chart metadata (Chart.yaml) missing")
+	}
+	if c.Metadata.KubeVersion == "" {
+		return nil, nil
+	}
+	constraint, err := semver.NewConstraint(c.Metadata.KubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeVersion %q: %s", c.Metadata.KubeVersion, err)
+	}
+	return constraint, nil
+}
+
+// IsCompatible reports whether a chart supports the Kubernetes version
+// clusterVersion, such as "v1.19.3", according to GetKubeVersion.
+//
+// Any pre-release or build suffix on clusterVersion, such as the "-gke.1"
+// that hosted providers add, is ignored. A chart that does not declare a
+// kubeVersion is compatible with every cluster.
+func IsCompatible(c *chart.Chart, clusterVersion string) (bool, error) {
+	constraint, err := GetKubeVersion(c)
+	if err != nil {
+		return false, err
+	}
+	if constraint == nil {
+		return true, nil
+	}
+	v, err := semver.NewVersion(clusterVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid Kubernetes version %q: %s", clusterVersion, err)
+	}
+	v, err = semver.NewVersion(fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch()))
+	if err != nil {
+		return false, err
+	}
+	return constraint.Check(v), nil
+}
+
+// CheckKubeVersion reports whether a chart can be installed on a cluster
+// running the Kubernetes version actual, such as "v1.19.3".
+//
+// Unlike IsCompatible, which only looks at c itself, the kubeVersion of every
+// dependency is checked as well, since all of them are installed together.
+// This makes it suitable for gating an install. An error is returned if
+// actual, or the kubeVersion of any chart, is not valid.
+func CheckKubeVersion(c *chart.Chart, actual string) (bool, error) {
+	ok, err := IsCompatible(c, actual)
+	if err != nil || !ok {
+		return false, err
+	}
+	for _, dep := range c.Dependencies {
+		ok, err := CheckKubeVersion(dep, actual)
+		if err != nil {
+			if dep.Metadata == nil {
+				return false, err
+			}
+			return false, fmt.Errorf("dependency %s: %s", dep.Metadata.Name, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
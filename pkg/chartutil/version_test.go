@@ -0,0 +1,131 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestIncrementVersion(t *testing.T) {
+	tests := []struct {
+		version, component, expect string
+	}{
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "patch", "1.2.4"},
+		{"0.1.0-beta.1+f334a6789", "patch", "0.1.1"},
+	}
+	for _, tt := range tests {
+		c := &chart.Chart{Metadata: &chart.Metadata{Name: "ahab", Version: tt.version}}
+		nc, err := IncrementVersion(c, tt.component)
+		if err != nil {
+			t.Errorf("%s %s: %s", tt.version, tt.component, err)
+			continue
+		}
+		if nc.Metadata.Version != tt.expect {
+			t.Errorf("%s %s: expected %s, got %s", tt.version, tt.component, tt.expect, nc.Metadata.Version)
+		}
+		if c.Metadata.Version != tt.version {
+			t.Errorf("Expected original version to be unchanged, got %s", c.Metadata.Version)
+		}
+	}
+
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "ahab", Version: "one"}}
+	if _, err := IncrementVersion(c, "patch"); err == nil {
+		t.Error("Expected error for invalid version")
+	}
+	c.Metadata.Version = "1.2.3"
+	if _, err := IncrementVersion(c, "micro"); err == nil {
+		t.Error("Expected error for unknown component")
+	}
+}
+
+func TestIsCompatible(t *testing.T) {
+	c := &chart.Chart{Metadata: &chart.Metadata{Name: "ahab"}}
+	if constraint, err := GetKubeVersion(c); err != nil || constraint != nil {
+		t.Errorf("Expected no constraint, got %v (%v)", constraint, err)
+	}
+	if ok, err := IsCompatible(c, "v1.5.0"); err != nil || !ok {
+		t.Errorf("Expected a chart without kubeVersion to be compatible, got %t (%v)", ok, err)
+	}
+
+	c.Metadata.KubeVersion = ">=1.19"
+	if constraint, err := GetKubeVersion(c); err != nil || constraint == nil {
+		t.Fatalf("Expected a constraint, got %v", err)
+	}
+	tests := []struct {
+		version string
+		expect  bool
+	}{
+		{"v1.19.0", true},
+		{"1.21.3", true},
+		{"v1.20.4-gke.1500", true},
+		{"v1.18.9", false},
+	}
+	for _, tt := range tests {
+		ok, err := IsCompatible(c, tt.version)
+		if err != nil {
+			t.Errorf("%s: %s", tt.version, err)
+		} else if ok != tt.expect {
+			t.Errorf("%s: expected %t, got %t", tt.version, tt.expect, ok)
+		}
+	}
+	if _, err := IsCompatible(c, "not a version"); err == nil {
+		t.Error("Expected an error for an invalid cluster version")
+	}
+
+	c.Metadata.KubeVersion = "not a range"
+	if _, err := GetKubeVersion(c); err == nil {
+		t.Error("Expected an error for an invalid kubeVersion")
+	}
+}
+
+func TestCheckKubeVersion(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "ahab", KubeVersion: ">=1.16"},
+		Dependencies: []*chart.Chart{
+			{Metadata: &chart.Metadata{Name: "moby", KubeVersion: ">=1.19.0, <1.25.0"}},
+			{Metadata: &chart.Metadata{Name: "dick"}},
+		},
+	}
+	tests := []struct {
+		version string
+		expect  bool
+	}{
+		{"v1.19.0", true},
+		{"v1.24.9-eks.2", true},
+		{"v1.17.0", false},
+		{"v1.25.0", false},
+		{"v1.15.0", false},
+	}
+	for _, tt := range tests {
+		ok, err := CheckKubeVersion(c, tt.version)
+		if err != nil {
+			t.Errorf("%s: %s", tt.version, err)
+		} else if ok != tt.expect {
+			t.Errorf("%s: expected %t, got %t", tt.version, tt.expect, ok)
+		}
+	}
+
+	c.Dependencies[1].Metadata.KubeVersion = "not a range"
+	if _, err := CheckKubeVersion(c, "v1.20.0"); err == nil || !strings.Contains(err.Error(), "dependency dick") {
+		t.Errorf("Expected an error for dick's kubeVersion, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"sort"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// SortByWeight returns charts in the order they should be installed when
+// they are installed together: by the weight in their Chart.yaml, lowest
+// first, and then by name. Charts without a weight have a weight of 0. The
+// sort is stable, so charts with the same weight and name keep their order.
+// The charts slice itself is not modified.
+//
+// A chart's weight only orders whole charts against each other. It has no
+// effect on the hooks of a chart's release, or on the order in which the
+// resources of a single release are installed, which is still by kind.
+// So a pre-install hook of a heavier chart runs after every resource of a
+// lighter chart has been installed, not before.
+func SortByWeight(charts []*chart.Chart) []*chart.Chart {
+	sorted := make([]*chart.Chart, len(charts))
+	copy(sorted, charts)
+	sort.Stable(byWeight(sorted))
+	return sorted
+}
+
+// byWeight sorts charts by weight and then by name.
+type byWeight []*chart.Chart
+
+func (b byWeight) Len() int      { return len(b) }
+func (b byWeight) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byWeight) Less(i, j int) bool {
+	wi, ni := weightAndName(b[i])
+	wj, nj := weightAndName(b[j])
+	if wi != wj {
+		return wi < wj
+	}
+	return ni < nj
+}
+
+// weightAndName returns the weight and name of c, which may lack metadata.
+func weightAndName(c *chart.Chart) (int32, string) {
+	if c.Metadata == nil {
+		return 0, ""
+	}
+	return c.Metadata.Weight, c.Metadata.Name
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"testing"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestSortByWeight(t *testing.T) {
+	m := func(name string, weight int32) *chart.Chart {
+		return &chart.Chart{Metadata: &chart.Metadata{Name: name, Weight: weight}}
+	}
+	charts := []*chart.Chart{
+		m("web", 10),
+		m("redis", 0),
+		m("mysql", -5),
+		m("cache", 0),
+		m("api", 10),
+	}
+
+	sorted := SortByWeight(charts)
+	expect := []string{"mysql", "cache", "redis", "api", "web"}
+	for i, name := range expect {
+		if sorted[i].Metadata.Name != name {
+			t.Errorf("Expected %s at %d, got %s", name, i, sorted[i].Metadata.Name)
+		}
+	}
+	if charts[0].Metadata.Name != "web" {
+		t.Error("Expected the input to be unmodified")
+	}
+
+	c, err := UnmarshalChartfile([]byte("name: ahab\nversion: 0.1.0\nweight: 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Weight != 3 {
+		t.Errorf("Expected weight 3 from Chart.yaml, got %d", c.Weight)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build go1.19
+// +build go1.19
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstd support comes from github.com/klauspost/compress, which requires Go
+// 1.19. Brotli is not supported: unlike gzip and zstd, a brotli stream has no
+// magic number, so it cannot be told apart from other data.
+
+func init() {
+	RegisterArchiveFormat("zstd", zstdMagic, func(in io.Reader) (io.Reader, error) {
+		d, err := zstd.NewReader(in)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	})
+}
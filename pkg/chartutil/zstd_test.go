@@ -0,0 +1,88 @@
+//go:build go1.19
+// +build go1.19
+
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chartutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestLoadZstd(t *testing.T) {
+	tgz, err := ioutil.ReadFile("testdata/frobnitz-1.2.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(tgz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Write(tarball); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to load zstd archive: %s", err)
+	}
+	verifyFrobnitz(t, c)
+
+	tmp, err := ioutil.TempDir("", "helm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	name := filepath.Join(tmp, "frobnitz-1.2.3.tar.zst")
+	if err := ioutil.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = Load(name)
+	if err != nil {
+		t.Fatalf("Failed to load %s: %s", name, err)
+	}
+	verifyFrobnitz(t, c)
+
+	if _, err := LoadArchive(bytes.NewReader(zstdMagic)); err == nil {
+		t.Error("Expected an error for a truncated zstd stream")
+	}
+
+	if names := archiveFormatNames(); !reflect.DeepEqual(names, []string{"gzip", "zstd"}) {
+		t.Errorf("Expected gzip and zstd to be registered, got %v", names)
+	}
+}
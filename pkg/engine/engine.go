@@ -108,6 +108,9 @@ func toYaml(v interface{}) string {
 // that section of the values will be passed into the "foo" chart. And if that
 // section contains a value named "bar", that value will be passed on to the
 // bar chart during render time.
+//
+// The template context set with Values.SetContext is not scoped in this way:
+// every chart in the tree sees the same .Context.
 func (e *Engine) Render(chrt *chart.Chart, values chartutil.Values) (map[string]string, error) {
 	// Render the charts
 	tmap := allTemplates(chrt, values)
@@ -230,6 +233,10 @@ func recAllTpls(c *chart.Chart, templates map[string]renderable, parentVals char
 			"Chart":   c.Metadata,
 			"Files":   chartutil.NewFiles(c.Files),
 		}
+		// The template context is shared by the whole tree.
+		if ctx, ok := parentVals[chartutil.ContextKey]; ok {
+			cvals[chartutil.ContextKey] = ctx
+		}
 	}
 
 	newParentID := c.Metadata.Name
@@ -249,6 +249,38 @@ func TestRenderDependency(t *testing.T) {
 
 }
 
+func TestRenderContext(t *testing.T) {
+	e := New()
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "outerchart"},
+		Templates: []*chart.Template{
+			{Name: "templates/outer", Data: []byte(`outer {{.Context.region}}`)},
+		},
+		Dependencies: []*chart.Chart{
+			{
+				Metadata: &chart.Metadata{Name: "innerchart"},
+				Templates: []*chart.Template{
+					{Name: "templates/inner", Data: []byte(`inner {{.Context.region}}`)},
+				},
+			},
+		},
+	}
+
+	vals := chartutil.Values{"Values": map[string]interface{}{}}
+	vals.SetContext(map[string]interface{}{"region": "pacific"})
+	out, err := e.Render(ch, vals)
+	if err != nil {
+		t.Fatalf("failed to render chart: %s", err)
+	}
+
+	if out["outerchart/templates/outer"] != "outer pacific" {
+		t.Errorf("Unexpected outer output: %q", out["outerchart/templates/outer"])
+	}
+	if out["outerchart/charts/innerchart/templates/inner"] != "inner pacific" {
+		t.Errorf("Unexpected inner output: %q", out["outerchart/charts/innerchart/templates/inner"])
+	}
+}
+
 func TestRenderNestedValues(t *testing.T) {
 	e := New()
 
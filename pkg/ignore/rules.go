@@ -34,6 +34,11 @@ const HelmIgnore = ".helmignore"
 // Parse() and ParseFile() will construct and populate new Rules.
 // Empty() will create an immutable empty ruleset.
 type Rules struct {
+	// CaseInsensitive, if set, matches patterns without regard to case, so
+	// *.txt also ignores NOTES.TXT. The default is to match case-sensitively,
+	// as Helm always has, whatever the file system does.
+	CaseInsensitive bool
+
 	patterns []*pattern
 }
 
@@ -157,7 +162,7 @@ func (r *Rules) parseRule(rule string) error {
 		// Require path matches the root path.
 		p.match = func(n string, fi os.FileInfo) bool {
 			rule = strings.TrimPrefix(rule, "/")
-			ok, err := filepath.Match(rule, n)
+			ok, err := r.fnmatch(rule, n)
 			if err != nil {
 				log.Printf("Failed to compile %q: %s", rule, err)
 				return false
@@ -167,7 +172,7 @@ func (r *Rules) parseRule(rule string) error {
 	} else if strings.Contains(rule, "/") {
 		// require structural match.
 		p.match = func(n string, fi os.FileInfo) bool {
-			ok, err := filepath.Match(rule, n)
+			ok, err := r.fnmatch(rule, n)
 			if err != nil {
 				log.Printf("Failed to compile %q: %s", rule, err)
 				return false
@@ -179,7 +184,7 @@ func (r *Rules) parseRule(rule string) error {
 			// When there is no slash in the pattern, we evaluate ONLY the
 			// filename.
 			n = filepath.Base(n)
-			ok, err := filepath.Match(rule, n)
+			ok, err := r.fnmatch(rule, n)
 			if err != nil {
 				log.Printf("Failed to compile %q: %s", rule, err)
 				return false
@@ -192,6 +197,15 @@ func (r *Rules) parseRule(rule string) error {
 	return nil
 }
 
+// fnmatch reports whether name matches the shell pattern rule, folding case
+// if the rules are case-insensitive.
+func (r *Rules) fnmatch(rule, name string) (bool, error) {
+	if r.CaseInsensitive {
+		rule, name = strings.ToLower(rule), strings.ToLower(name)
+	}
+	return filepath.Match(rule, name)
+}
+
 // matcher is a function capable of computing a match.
 //
 // It returns true if the rule matches.
@@ -134,6 +134,42 @@ func TestIgnore(t *testing.T) {
 	}
 }
 
+func TestIgnoreCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		name        string
+		sensitive   bool
+		insensitive bool
+	}{
+		{`helm.txt`, "helm.txt", true, true},
+		{`HELM.txt`, "helm.txt", false, true},
+		{`*.TXT`, "cargo/a.txt", false, true},
+		{`Cargo/*.txt`, "cargo/a.txt", false, true},
+		{`/A.txt`, "a.txt", false, true},
+		{`CARGO/`, "cargo", false, true},
+		{`!HELM.txt`, "helm.txt", true, false},
+	}
+
+	for _, test := range tests {
+		r, err := parseString(test.pattern)
+		if err != nil {
+			t.Fatalf("Failed to parse: %s", err)
+		}
+		fi, err := os.Stat(filepath.Join(testdata, test.name))
+		if err != nil {
+			t.Fatalf("Fixture missing: %s", err)
+		}
+
+		if r.Ignore(test.name, fi) != test.sensitive {
+			t.Errorf("Expected %q to be %v for pattern %q", test.name, test.sensitive, test.pattern)
+		}
+		r.CaseInsensitive = true
+		if r.Ignore(test.name, fi) != test.insensitive {
+			t.Errorf("Expected %q to be %v for case-insensitive pattern %q", test.name, test.insensitive, test.pattern)
+		}
+	}
+}
+
 func TestAddDefaults(t *testing.T) {
 	r := Rules{}
 	r.AddDefaults()
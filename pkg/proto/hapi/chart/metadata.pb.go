@@ -71,6 +71,15 @@ type Metadata struct {
 	Icon string `protobuf:"bytes,9,opt,name=icon" json:"icon,omitempty"`
 	// The API Version of this chart.
 	ApiVersion string `protobuf:"bytes,10,opt,name=apiVersion" json:"apiVersion,omitempty"`
+	// The charts this chart depends on. Only used by apiVersion v2 charts.
+	Dependencies []*Dependency `protobuf:"bytes,11,rep,name=dependencies" json:"dependencies,omitempty"`
+	// The type of the chart: application or library. Only used by apiVersion v2 charts.
+	Type string `protobuf:"bytes,12,opt,name=type" json:"type,omitempty"`
+	// A SemVer range of the Kubernetes versions this chart supports.
+	KubeVersion string `protobuf:"bytes,13,opt,name=kubeVersion" json:"kubeVersion,omitempty"`
+	// The order in which to install this chart relative to others installed
+	// together with it. Lower weights are installed first.
+	Weight int32 `protobuf:"varint,14,opt,name=weight" json:"weight,omitempty"`
 }
 
 func (m *Metadata) Reset()                    { *m = Metadata{} }
@@ -85,33 +94,62 @@ func (m *Metadata) GetMaintainers() []*Maintainer {
 	return nil
 }
 
+func (m *Metadata) GetDependencies() []*Dependency {
+	if m != nil {
+		return m.Dependencies
+	}
+	return nil
+}
+
+// Dependency describes a chart upon which another chart depends.
+type Dependency struct {
+	// Name is the name of the dependency. It must match the name in the
+	// dependency's Chart.yaml.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Version is the version (range) of this chart.
+	Version string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	// Repository is the URL to the repository.
+	Repository string `protobuf:"bytes,3,opt,name=repository" json:"repository,omitempty"`
+}
+
+func (m *Dependency) Reset()                    { *m = Dependency{} }
+func (m *Dependency) String() string            { return proto.CompactTextString(m) }
+func (*Dependency) ProtoMessage()               {}
+func (*Dependency) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
+
 func init() {
 	proto.RegisterType((*Maintainer)(nil), "hapi.chart.Maintainer")
 	proto.RegisterType((*Metadata)(nil), "hapi.chart.Metadata")
+	proto.RegisterType((*Dependency)(nil), "hapi.chart.Dependency")
 	proto.RegisterEnum("hapi.chart.Metadata_Engine", Metadata_Engine_name, Metadata_Engine_value)
 }
 
 func init() { proto.RegisterFile("hapi/chart/metadata.proto", fileDescriptor2) }
 
 var fileDescriptor2 = []byte{
-	// 290 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x6c, 0x91, 0x4d, 0x4b, 0xf4, 0x30,
-	0x14, 0x85, 0xdf, 0x4e, 0xbf, 0x6f, 0x37, 0xc3, 0xe5, 0x65, 0x88, 0x2e, 0xa4, 0x74, 0xd5, 0x55,
-	0x07, 0x14, 0xc4, 0xb5, 0x20, 0x2e, 0x74, 0x3a, 0x52, 0xfc, 0x00, 0x77, 0xb1, 0x0d, 0x36, 0x68,
-	0x93, 0x92, 0x44, 0xc5, 0xff, 0xe8, 0x8f, 0x92, 0xa6, 0x9d, 0x99, 0x2e, 0xdc, 0xdd, 0x73, 0x9e,
-	0x9e, 0xdb, 0x9c, 0x04, 0x8e, 0x5a, 0xda, 0xf3, 0x75, 0xdd, 0x52, 0x65, 0xd6, 0x1d, 0x33, 0xb4,
-	0xa1, 0x86, 0x16, 0xbd, 0x92, 0x46, 0x22, 0x0c, 0xa8, 0xb0, 0x28, 0x3b, 0x07, 0xd8, 0x50, 0x2e,
-	0x0c, 0xe5, 0x82, 0x29, 0x44, 0xf0, 0x04, 0xed, 0x18, 0x71, 0x52, 0x27, 0x8f, 0x2b, 0x3b, 0xe3,
-	0x7f, 0xf0, 0x59, 0x47, 0xf9, 0x3b, 0x59, 0x58, 0x73, 0x14, 0xd9, 0xcf, 0x02, 0xa2, 0xcd, 0xb4,
-	0xf6, 0xcf, 0x18, 0x82, 0xd7, 0xca, 0x8e, 0x4d, 0x29, 0x3b, 0x23, 0x81, 0x50, 0xcb, 0x0f, 0x55,
-	0x33, 0x4d, 0xdc, 0xd4, 0xcd, 0xe3, 0x6a, 0x27, 0x07, 0xf2, 0xc9, 0x94, 0xe6, 0x52, 0x10, 0xcf,
-	0x06, 0x76, 0x12, 0x53, 0x48, 0x1a, 0xa6, 0x6b, 0xc5, 0x7b, 0x33, 0x50, 0xdf, 0xd2, 0xb9, 0x85,
-	0xc7, 0x10, 0xbd, 0xb1, 0xef, 0x2f, 0xa9, 0x1a, 0x4d, 0x02, 0xbb, 0x76, 0xaf, 0xf1, 0x02, 0x92,
-	0x6e, 0x5f, 0x4f, 0x93, 0x30, 0x75, 0xf3, 0xe4, 0x74, 0x55, 0x1c, 0x2e, 0xa0, 0x38, 0xb4, 0xaf,
-	0xe6, 0x9f, 0xe2, 0x0a, 0x02, 0x26, 0x5e, 0xb9, 0x60, 0x24, 0xb2, 0xbf, 0x9c, 0xd4, 0xd0, 0x8b,
-	0xd7, 0x52, 0x90, 0x78, 0xec, 0x35, 0xcc, 0x78, 0x02, 0x40, 0x7b, 0xfe, 0x38, 0x15, 0x00, 0x4b,
-	0x66, 0x4e, 0x96, 0x42, 0x70, 0x35, 0xa6, 0x13, 0x08, 0x1f, 0xca, 0x9b, 0x72, 0xfb, 0x54, 0x2e,
-	0xff, 0x61, 0x0c, 0xfe, 0xf5, 0xf6, 0xfe, 0xee, 0x76, 0xe9, 0x5c, 0x86, 0xcf, 0xbe, 0x3d, 0xce,
-	0x4b, 0x60, 0x9f, 0xe8, 0xec, 0x37, 0x00, 0x00, 0xff, 0xff, 0x65, 0x86, 0x8b, 0xda, 0xbf, 0x01,
+	// 370 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x6d, 0x52, 0xcb, 0x4a, 0xc3, 0x40,
+	0x14, 0xb5, 0x8f, 0x24, 0xed, 0x4d, 0x95, 0x32, 0x48, 0x19, 0x5d, 0x48, 0xc8, 0xca, 0x55, 0x0a,
+	0x0a, 0x22, 0x2e, 0x45, 0x71, 0xa1, 0x6d, 0x25, 0xf8, 0x80, 0xee, 0xd2, 0x64, 0x68, 0x86, 0x9a,
+	0x4c, 0x98, 0x4c, 0x2d, 0xf9, 0x51, 0xbf, 0xc7, 0x99, 0x49, 0xd2, 0xa4, 0xe8, 0x22, 0x70, 0xcf,
+	0x39, 0x73, 0x1f, 0xe7, 0xde, 0xc0, 0x59, 0x1c, 0x64, 0x74, 0x1a, 0xc6, 0x01, 0x17, 0xd3, 0x84,
+	0x88, 0x20, 0x0a, 0x44, 0xe0, 0x65, 0x9c, 0x09, 0x86, 0x40, 0x49, 0x9e, 0x96, 0xdc, 0x1b, 0x80,
+	0x59, 0x40, 0x53, 0x21, 0x3f, 0xc2, 0x11, 0x82, 0x7e, 0x1a, 0x24, 0x04, 0x77, 0x9c, 0xce, 0xe5,
+	0xd0, 0xd7, 0x31, 0x3a, 0x05, 0x83, 0x24, 0x01, 0xfd, 0xc2, 0x5d, 0x4d, 0x96, 0xc0, 0xfd, 0xe9,
+	0xc1, 0x60, 0x56, 0x95, 0xfd, 0x37, 0x4d, 0x72, 0x31, 0x93, 0x5c, 0x99, 0xa5, 0x63, 0x84, 0xc1,
+	0xca, 0xd9, 0x96, 0x87, 0x24, 0xc7, 0x3d, 0xa7, 0x27, 0xe9, 0x1a, 0x2a, 0xe5, 0x9b, 0xf0, 0x9c,
+	0xb2, 0x14, 0xf7, 0x75, 0x42, 0x0d, 0x91, 0x03, 0x76, 0x44, 0xf2, 0x90, 0xd3, 0x4c, 0x28, 0xd5,
+	0xd0, 0x6a, 0x9b, 0x42, 0xe7, 0x30, 0xd8, 0x90, 0x62, 0xc7, 0x78, 0x94, 0x63, 0x53, 0x97, 0xdd,
+	0x63, 0x74, 0x0b, 0x76, 0xb2, 0xb7, 0x97, 0x63, 0x4b, 0xca, 0xf6, 0xd5, 0xc4, 0x6b, 0x16, 0xe0,
+	0x35, 0xee, 0xfd, 0xf6, 0x53, 0x34, 0x01, 0x93, 0xa4, 0x6b, 0x19, 0xe3, 0x81, 0x6e, 0x59, 0x21,
+	0xe5, 0x8b, 0x86, 0x72, 0x90, 0x61, 0xe9, 0x4b, 0xc5, 0xe8, 0x02, 0x40, 0x16, 0xfc, 0xa8, 0x0c,
+	0x80, 0x56, 0x5a, 0x0c, 0xba, 0x83, 0x51, 0x44, 0x32, 0x92, 0x46, 0x24, 0x0d, 0xa9, 0x34, 0x6f,
+	0xff, 0x1d, 0xe3, 0xa1, 0xd6, 0x0b, 0xff, 0xe0, 0xad, 0xea, 0x27, 0x8a, 0x8c, 0xe0, 0x51, 0xd9,
+	0x4f, 0xc5, 0x6a, 0x27, 0x9b, 0xed, 0x8a, 0xd4, 0x0d, 0x8f, 0xcb, 0x9d, 0xb4, 0x28, 0x35, 0xfd,
+	0x8e, 0xd0, 0x75, 0x2c, 0xf0, 0x89, 0x14, 0x0d, 0xbf, 0x42, 0xae, 0x03, 0xe6, 0x63, 0xe9, 0xc3,
+	0x06, 0xeb, 0x7d, 0xfe, 0x3c, 0x5f, 0x7c, 0xce, 0xc7, 0x47, 0x68, 0x08, 0xc6, 0xd3, 0xe2, 0xed,
+	0xf5, 0x65, 0xdc, 0x71, 0x97, 0x00, 0xcd, 0x2c, 0xff, 0x5e, 0xb6, 0x75, 0xab, 0xee, 0xe1, 0xad,
+	0xe4, 0x1e, 0x38, 0xc9, 0x58, 0x4e, 0x05, 0xe3, 0x85, 0x3c, 0xb1, 0xde, 0x43, 0xc3, 0xdc, 0x5b,
+	0x4b, 0x43, 0xbb, 0x5d, 0x99, 0xfa, 0x47, 0xbc, 0xfe, 0x05, 0x08, 0x75, 0xc5, 0xba, 0xa5, 0x02,
 	0x00, 0x00,
 }
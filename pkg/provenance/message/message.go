@@ -0,0 +1,90 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*Package message builds and signs the message block of a Helm provenance file.
+
+It holds the parts of signing that both pkg/provenance and pkg/chartutil
+need. pkg/provenance loads charts with pkg/chartutil, so chartutil cannot
+import it, but both can import this package.
+*/
+package message // import "k8s.io/helm/pkg/provenance/message"
+
+import (
+	"bytes"
+	"crypto"
+	"io"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+var defaultPGPConfig = packet.Config{
+	DefaultHash: crypto.SHA512,
+}
+
+// sums is the checksum section of a message block. It marshals as
+// provenance.SumCollection does.
+type sums struct {
+	Files map[string]string `json:"files"`
+}
+
+// New returns the message block for a chart archive named base, whose
+// SHA256 sum is chash, and whose Chart.yaml is md.
+func New(md *chart.Metadata, base, chash string) (*bytes.Buffer, error) {
+	var b *bytes.Buffer
+	s := &sums{
+		Files: map[string]string{
+			base: "sha256:" + chash,
+		},
+	}
+
+	// Buffer a hash + checksums YAML file
+	data, err := yaml.Marshal(md)
+	if err != nil {
+		return b, err
+	}
+
+	// FIXME: YAML uses ---\n as a file start indicator, but this is not legal in a PGP
+	// clearsign block. So we use ...\n, which is the YAML document end marker.
+	// http://yaml.org/spec/1.2/spec.html#id2800168
+	b = bytes.NewBuffer(data)
+	b.WriteString("\n...\n")
+
+	data, err = yaml.Marshal(s)
+	if err != nil {
+		return b, err
+	}
+	b.Write(data)
+
+	return b, nil
+}
+
+// ClearSign returns a clear signature of the message block b, made with key.
+func ClearSign(key *packet.PrivateKey, b io.Reader) (string, error) {
+	out := bytes.NewBuffer(nil)
+
+	// Sign the buffer
+	w, err := clearsign.Encode(out, key, &defaultPGPConfig)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(w, b)
+	w.Close()
+	return out.String(), err
+}
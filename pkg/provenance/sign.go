@@ -35,12 +35,9 @@ import (
 
 	"k8s.io/helm/pkg/chartutil"
 	hapi "k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/provenance/message"
 )
 
-var defaultPGPConfig = packet.Config{
-	DefaultHash: crypto.SHA512,
-}
-
 // SumCollection represents a collection of file and image checksums.
 //
 // Files are of the form:
@@ -214,21 +211,11 @@ func (s *Signatory) ClearSign(chartpath string) (string, error) {
 		return "", errors.New("cannot sign a directory")
 	}
 
-	out := bytes.NewBuffer(nil)
-
 	b, err := messageBlock(chartpath)
 	if err != nil {
 		return "", nil
 	}
-
-	// Sign the buffer
-	w, err := clearsign.Encode(out, s.Entity.PrivateKey, &defaultPGPConfig)
-	if err != nil {
-		return "", err
-	}
-	_, err = io.Copy(w, b)
-	w.Close()
-	return out.String(), err
+	return message.ClearSign(s.Entity.PrivateKey, b)
 }
 
 // Verify checks a signature and verifies that it is legit for a chart.
@@ -311,38 +298,13 @@ func messageBlock(chartpath string) (*bytes.Buffer, error) {
 		return b, err
 	}
 
-	base := filepath.Base(chartpath)
-	sums := &SumCollection{
-		Files: map[string]string{
-			base: "sha256:" + chash,
-		},
-	}
-
 	// Load the archive into memory.
 	chart, err := chartutil.LoadFile(chartpath)
 	if err != nil {
 		return b, err
 	}
 
-	// Buffer a hash + checksums YAML file
-	data, err := yaml.Marshal(chart.Metadata)
-	if err != nil {
-		return b, err
-	}
-
-	// FIXME: YAML uses ---\n as a file start indicator, but this is not legal in a PGP
-	// clearsign block. So we use ...\n, which is the YAML document end marker.
-	// http://yaml.org/spec/1.2/spec.html#id2800168
-	b = bytes.NewBuffer(data)
-	b.WriteString("\n...\n")
-
-	data, err = yaml.Marshal(sums)
-	if err != nil {
-		return b, err
-	}
-	b.Write(data)
-
-	return b, nil
+	return message.New(chart.Metadata, filepath.Base(chartpath), chash)
 }
 
 // parseMessageBlock